@@ -0,0 +1,122 @@
+// Command bootstrap-admin creates the first admin account, or promotes an
+// existing account to admin, on a fresh deployment. It refuses to run once
+// any admin exists; from then on admins manage roles through the API.
+//
+//	go run ./cmd/bootstrap-admin -username alice [-email alice@example.com]
+//
+// The password for a new account is read from BOOTSTRAP_ADMIN_PASSWORD, or
+// from standard input when that is unset.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const minPasswordLength = 8
+
+func main() {
+	usernameFlag := flag.String("username", "", "username of the admin to create or promote")
+	emailFlag := flag.String("email", "", "optional email address for a new account")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found — continuing with system environment variables")
+	}
+	if *usernameFlag == "" {
+		log.Fatal("-username is required")
+	}
+	if err := utils.InitArgon2(); err != nil {
+		log.Fatal("Argon2 config error: ", err)
+	}
+	database.ConnectMongoDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	userCollection := database.GetCollection("users")
+
+	admins, err := userCollection.CountDocuments(ctx, bson.M{"role": models.RoleAdmin})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if admins > 0 {
+		log.Fatal("An admin already exists; use PUT /admin/users/:id/role instead")
+	}
+
+	existing := models.User{}
+	err = userCollection.FindOne(
+		ctx,
+		bson.M{"username": *usernameFlag},
+		options.FindOne().SetCollation(database.UsernameCollation),
+	).Decode(&existing)
+	if err == nil {
+		if _, err := userCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": existing.ID},
+			bson.M{"$set": bson.M{"role": models.RoleAdmin}},
+		); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Promoted %s (%s) to admin\n", existing.Username, existing.ID.Hex())
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Fatal(err)
+	}
+
+	username, err := utils.NormalizeUsername(*usernameFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	email := ""
+	if *emailFlag != "" {
+		if email, err = utils.NormalizeEmail(*emailFlag); err != nil {
+			log.Fatal("Invalid email address")
+		}
+	}
+
+	password := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Print("Password for the new admin: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatal("could not read password: ", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPasswordLength {
+		log.Fatalf("Password must be at least %d characters", minPasswordLength)
+	}
+	hashed, err := utils.HashPasswordSecure(password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	admin := models.User{
+		ID:        primitive.NewObjectID(),
+		Username:  username,
+		Password:  hashed,
+		Email:     email,
+		PushToken: make([]string, 0),
+		Role:      models.RoleAdmin,
+	}
+	if _, err := userCollection.InsertOne(ctx, admin); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Created admin %s (%s)\n", admin.Username, admin.ID.Hex())
+}
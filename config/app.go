@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// AppURL is the public base URL of the Voxa web app, used to build links in
+// outgoing mail.
+func AppURL() string {
+	url := os.Getenv("APP_URL")
+	if url == "" {
+		return "http://localhost:3000"
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+// OAuthClientRedirect is where social logins land once finished. Tokens, an
+// MFA challenge or an error are passed in the URL fragment.
+func OAuthClientRedirect() string {
+	url := os.Getenv("OAUTH_CLIENT_REDIRECT")
+	if url == "" {
+		return AppURL() + "/oauth/complete"
+	}
+	return url
+}
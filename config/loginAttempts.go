@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+)
+
+var LoginAttempts utils.AttemptStore
+
+// InitLoginAttempts picks where failed logins are counted from
+// LOGIN_ATTEMPT_STORE: "mongo" (the default) shares the counts between
+// replicas, "memory" keeps them per process. It needs the database, so call
+// it after database.ConnectMongoDB.
+func InitLoginAttempts() error {
+	switch store := os.Getenv("LOGIN_ATTEMPT_STORE"); store {
+	case "", "mongo":
+		LoginAttempts = utils.NewMongoAttemptStore(database.GetCollection("login_attempts"))
+	case "memory":
+		LoginAttempts = utils.NewMemoryAttemptStore()
+	default:
+		return fmt.Errorf("unknown LOGIN_ATTEMPT_STORE %q", store)
+	}
+	fmt.Println("Login attempt store initialized")
+	return nil
+}
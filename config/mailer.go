@@ -0,0 +1,41 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Investorharry19/voxa-golang-server/utils"
+)
+
+var Mailer utils.Mailer
+
+// InitMailer sets up SMTP delivery from SMTP_HOST and friends. Without a
+// relay nothing would ever be delivered, so it refuses to start unless
+// MAIL_IN_MEMORY=true asks for the in-memory mailer of local development.
+func InitMailer() error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		if os.Getenv("MAIL_IN_MEMORY") != "true" {
+			return errors.New("SMTP_HOST is required; set MAIL_IN_MEMORY=true to keep mail in memory during development")
+		}
+		Mailer = &utils.MemoryMailer{}
+		fmt.Println("MAIL_IN_MEMORY set — outgoing mail is kept in memory only")
+		return nil
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	Mailer = &utils.SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	fmt.Println("Mailer initialized:", host)
+	return nil
+}
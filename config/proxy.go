@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProxyConfig reads which reverse proxies may report the client address from
+// TRUSTED_PROXIES, a comma-separated list of IPs and CIDR ranges. Requests
+// from those addresses take c.IP() from PROXY_HEADER (default
+// X-Forwarded-For); everything else, and every request when the list is
+// empty, uses the connection's own address.
+func ProxyConfig() (fiber.Config, error) {
+	proxies := make([]string, 0)
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fiber.Config{}, fmt.Errorf("TRUSTED_PROXIES: %q is not an IP or CIDR range", proxy)
+			}
+		}
+		proxies = append(proxies, proxy)
+	}
+	if len(proxies) == 0 {
+		return fiber.Config{}, nil
+	}
+
+	header := os.Getenv("PROXY_HEADER")
+	if header == "" {
+		header = fiber.HeaderXForwardedFor
+	}
+	return fiber.Config{
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          proxies,
+		ProxyHeader:             header,
+		EnableIPValidation:      true,
+	}, nil
+}
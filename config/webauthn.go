@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+var WebAuthn *webauthn.WebAuthn
+
+// InitWebAuthn sets up the passkey relying party. WEBAUTHN_RP_ID defaults to
+// the host of APP_URL and WEBAUTHN_RP_ORIGINS, a comma-separated list, to
+// APP_URL itself.
+func InitWebAuthn() error {
+	origins := make([]string, 0)
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{AppURL()}
+	}
+
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		appURL, err := url.Parse(AppURL())
+		if err != nil {
+			return fmt.Errorf("APP_URL: %w", err)
+		}
+		rpID = appURL.Hostname()
+	}
+
+	rpName := os.Getenv("WEBAUTHN_RP_NAME")
+	if rpName == "" {
+		rpName = "Voxa"
+	}
+
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+	})
+	if err != nil {
+		return err
+	}
+	WebAuthn = wa
+	return nil
+}
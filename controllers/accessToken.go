@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxAccessTokensPerUser  = 50
+	maxAccessTokenNameLen   = 100
+	maxAccessTokenLifetime  = 365
+	accessTokenPrefixLength = len(utils.PersonalAccessTokenPrefix) + 4
+)
+
+// CreateAccessToken godoc
+// @Summary Create Personal Access Token
+// @Description Create a long-lived token for scripts and integrations, limited to the given scopes (messages:read, messages:write, account:read). The token is only shown in this response.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param tokenData body models.CreateAccessTokenRequestDTO true "Token name, scopes and optional lifetime"
+// @Success 201 {object} utils.APIResponse{data=models.CreatedAccessTokenResponse} "Token created"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/tokens [post]
+func CreateAccessToken(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.CreateAccessTokenRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	name := strings.TrimSpace(requestData.Name)
+	if name == "" || len(name) > maxAccessTokenNameLen {
+		return utils.ErrorResponse(c, 400, "name is required and must be at most 100 characters")
+	}
+	if len(requestData.Scopes) == 0 {
+		return utils.ErrorResponse(c, 400, "At least one scope is required")
+	}
+	scopes := make([]string, 0, len(requestData.Scopes))
+	for _, scope := range requestData.Scopes {
+		if !slices.Contains(models.AccessTokenScopes, scope) {
+			return utils.ErrorResponse(c, 400, "Unknown scope "+scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if requestData.ExpiresInDays < 0 || requestData.ExpiresInDays > maxAccessTokenLifetime {
+		return utils.ErrorResponse(c, 400, "expiresInDays must be between 0 and 365")
+	}
+
+	tokenCollection := database.GetCollection("personal_access_tokens")
+	count, err := tokenCollection.CountDocuments(c.Context(), bson.M{"userId": userId, "revokedAt": bson.M{"$exists": false}})
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if count >= maxAccessTokensPerUser {
+		return utils.ErrorResponse(c, 400, "Token limit reached, revoke an unused token first")
+	}
+
+	raw, err := utils.GeneratePersonalAccessToken()
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	now := time.Now()
+	token := models.PersonalAccessToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    userId,
+		Name:      name,
+		TokenHash: utils.HashToken(raw),
+		Prefix:    raw[:accessTokenPrefixLength],
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if requestData.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, requestData.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if _, err := tokenCollection.InsertOne(c.Context(), token); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Token created", models.CreatedAccessTokenResponse{
+		AccessTokenResponse: models.AccessTokenToAccessTokenResponse(&token),
+		Token:               raw,
+	})
+}
+
+// GetAccessTokens godoc
+// @Summary List Personal Access Tokens
+// @Description List the authenticated user's active personal access tokens
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.AccessTokenResponse} "Active tokens"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/tokens [get]
+func GetAccessTokens(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	cursor, err := database.GetCollection("personal_access_tokens").Find(
+		c.Context(),
+		bson.M{
+			"userId":    userId,
+			"revokedAt": bson.M{"$exists": false},
+			"$or": []bson.M{
+				{"expiresAt": bson.M{"$exists": false}},
+				{"expiresAt": bson.M{"$gt": time.Now()}},
+			},
+		},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	tokens := make([]models.AccessTokenResponse, 0)
+	for cursor.Next(c.Context()) {
+		token := models.PersonalAccessToken{}
+		if err := cursor.Decode(&token); err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		tokens = append(tokens, models.AccessTokenToAccessTokenResponse(&token))
+	}
+
+	return utils.SuccessResponse(c, 200, "", tokens)
+}
+
+// RevokeAccessToken godoc
+// @Summary Revoke Personal Access Token
+// @Description Revoke one of the authenticated user's personal access tokens
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Token ID"
+// @Success 200 {object} utils.APIResponse "Token revoked"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 404 {object} utils.APIResponse "Token not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/tokens/{id} [delete]
+func RevokeAccessToken(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	tokenId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 404, "Token not found")
+	}
+
+	result, err := database.GetCollection("personal_access_tokens").UpdateOne(
+		c.Context(),
+		bson.M{"_id": tokenId, "userId": userId, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrorResponse(c, 404, "Token not found")
+	}
+
+	return utils.SuccessResponse(c, 200, "Token revoked", nil)
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recentLoginWindow is how fresh the session of an account without a
+// password must be to stand in for re-entering one.
+const recentLoginWindow = 10 * time.Minute
+
+// userOwnedCollections hold documents keyed by userId that go with the
+// account when it is deleted.
+var userOwnedCollections = []string{
+	"sessions",
+	"refresh_tokens",
+	"password_resets",
+	"user_identities",
+	"username_aliases",
+	"security_events",
+	"data_exports",
+	"personal_access_tokens",
+	"webauthn_credentials",
+	"prompts",
+}
+
+// queueExportArchives queues the Cloudinary archives of userID's exports
+// for destruction.
+func queueExportArchives(ctx context.Context, userID primitive.ObjectID) error {
+	cursor, err := database.GetCollection("data_exports").Find(
+		ctx,
+		bson.M{"userId": userID, "publicId": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"publicId": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	publicIDs := make([]string, 0)
+	for cursor.Next(ctx) {
+		export := models.DataExport{}
+		if err := cursor.Decode(&export); err != nil {
+			return err
+		}
+		publicIDs = append(publicIDs, export.PublicID)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return jobs.EnqueueMediaDeletions(ctx, publicIDs, api.File, "private")
+}
+
+// deleteUserData removes user, their inbox and everything keyed to them. The
+// user document goes last so an interrupted run can be repeated.
+func deleteUserData(ctx context.Context, user *models.User) error {
+	return database.RunInTransaction(ctx, func(ctx context.Context) error {
+		if _, err := jobs.DeleteMessages(ctx, bson.M{"ownerId": user.ID}); err != nil {
+			return err
+		}
+		if err := queueExportArchives(ctx, user.ID); err != nil {
+			return err
+		}
+		if user.AvatarPublicID != "" {
+			if err := jobs.EnqueueMediaDeletions(ctx, []string{user.AvatarPublicID}, "image", ""); err != nil {
+				return err
+			}
+		}
+		for _, name := range userOwnedCollections {
+			if _, err := database.GetCollection(name).DeleteMany(ctx, bson.M{"userId": user.ID}); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		_, err := database.GetCollection("users").DeleteOne(ctx, bson.M{"_id": user.ID})
+		return err
+	})
+}
+
+// DeleteAccount godoc
+// @Summary Delete Account
+// @Description Permanently delete the authenticated user's account, every message in their inbox and the stored audio. Requires the password; accounts without one (social login only) must have signed in within the last 10 minutes.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param deleteData body models.DeleteAccountRequestDTO true "Current password"
+// @Success 200 {object} utils.APIResponse "Account deleted"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Password is incorrect or sign-in is too old"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Server busy"
+// @Router /account [delete]
+func DeleteAccount(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	sessionId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("sessionId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.DeleteAccountRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	if user.Password != "" {
+		if requestData.Password == "" {
+			return utils.ErrorResponse(c, 400, "password is required")
+		}
+		passwordMatch, err := utils.VerifyPasswordSecure(user.Password, requestData.Password)
+		if errors.Is(err, utils.ErrHasherBusy) {
+			return utils.ErrorResponse(c, 503, "Server busy, please try again")
+		}
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		if !passwordMatch {
+			return utils.ErrorResponse(c, 401, "Password is incorrect")
+		}
+	} else {
+		session := models.Session{}
+		if err := database.GetCollection("sessions").FindOne(c.Context(), bson.M{"_id": sessionId}).Decode(&session); err != nil {
+			return utils.ErrorResponse(c, 401, "Bad request")
+		}
+		if time.Since(session.CreatedAt) > recentLoginWindow {
+			return utils.ErrorResponse(c, 401, "Please sign in again to confirm")
+		}
+	}
+
+	if err := deleteUserData(c.Context(), &user); err != nil {
+		log.Printf("account deletion for %s failed: %v", userId.Hex(), err)
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if err := config.LoginAttempts.Reset(c.Context(), accountLoginKey(userId)); err != nil {
+		log.Printf("could not reset failed logins for %s: %v", userId.Hex(), err)
+	}
+	jobs.TriggerMediaCleanup()
+
+	return utils.SuccessResponse(c, 200, "Account deleted", nil)
+}
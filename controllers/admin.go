@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetUserRole godoc
+// @Summary Set User Role
+// @Description Make a user a regular user, moderator or admin. Admins only; the last admin cannot be demoted.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param roleData body models.SetRoleRequestDTO true "New role: user, moderator or admin"
+// @Success 200 {object} utils.APIResponse "Role updated"
+// @Failure 400 {object} utils.APIResponse "Unknown role"
+// @Failure 403 {object} utils.APIResponse "Insufficient permissions"
+// @Failure 404 {object} utils.APIResponse "User not found"
+// @Failure 409 {object} utils.APIResponse "Cannot demote the last admin"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /admin/users/{id}/role [put]
+func SetUserRole(c *fiber.Ctx) error {
+	targetId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 404, "User not found")
+	}
+
+	requestData := models.SetRoleRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if !slices.Contains(models.Roles, requestData.Role) {
+		return utils.ErrorResponse(c, 400, fmt.Sprintf("role must be one of %v", models.Roles))
+	}
+
+	userCollection := database.GetCollection("users")
+	target := models.User{}
+	err = userCollection.FindOne(c.Context(), bson.M{"_id": targetId}).Decode(&target)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "User not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	demoting := target.EffectiveRole() == models.RoleAdmin && requestData.Role != models.RoleAdmin
+	if demoting {
+		admins, err := userCollection.CountDocuments(c.Context(), bson.M{"role": models.RoleAdmin})
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		if admins <= 1 {
+			return utils.ErrorResponse(c, 409, "Cannot demote the last admin")
+		}
+	}
+
+	if _, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": targetId},
+		bson.M{"$set": bson.M{"role": requestData.Role}},
+	); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if demoting {
+		// Two admins demoting each other can both pass the count above, so
+		// count again now the write is in and undo it if nobody is left.
+		// Whichever request counts last sees both writes, so at least one
+		// admin always remains.
+		admins, err := userCollection.CountDocuments(c.Context(), bson.M{"role": models.RoleAdmin})
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		if admins == 0 {
+			if _, err := userCollection.UpdateOne(
+				c.Context(),
+				bson.M{"_id": targetId, "role": requestData.Role},
+				bson.M{"$set": bson.M{"role": models.RoleAdmin}},
+			); err != nil {
+				return utils.ErrorResponse(c, 500, "Internal server error")
+			}
+			return utils.ErrorResponse(c, 409, "Cannot demote the last admin")
+		}
+	}
+	if target.EffectiveRole() != requestData.Role {
+		recordSecurityEvent(c, targetId, models.SecurityEventRoleChanged)
+	}
+
+	return utils.SuccessResponse(c, 200, "Role updated", nil)
+}
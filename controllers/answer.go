@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxAnswerTextLen = 2000
+
+// saveAnswer sets the caller's answer to messageID, replacing any earlier
+// one, and returns it. Audio the old answer pointed at is queued for
+// destruction.
+func saveAnswer(ctx context.Context, access *messageAccess, messageID primitive.ObjectID, answerType string, text *models.TextPayload, audio *models.AudioPayload, publish bool) (*models.Answer, error) {
+	now := time.Now()
+	set := bson.M{"type": answerType, "updatedAt": now}
+	unset := bson.M{}
+	if text != nil {
+		set["text"] = text
+		unset["audio"] = ""
+	} else {
+		set["audio"] = audio
+		unset["text"] = ""
+	}
+	update := bson.M{
+		"$set":         set,
+		"$unset":       unset,
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "createdAt": now},
+	}
+	if publish {
+		// $min keeps the original date when it is already published
+		update["$min"] = bson.M{"publishedAt": now}
+	}
+
+	previous := models.Answer{}
+	err := database.GetCollection("answers").FindOneAndUpdate(
+		ctx,
+		bson.M{"messageId": messageID, "ownerId": access.ownerID},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if previous.Audio != nil && previous.Audio.PublicID != "" {
+		if err := jobs.EnqueueMediaDeletions(ctx, []string{previous.Audio.PublicID}, "video", ""); err != nil {
+			log.Printf("could not queue replaced answer audio %s: %v", previous.Audio.PublicID, err)
+		}
+		jobs.TriggerMediaCleanup()
+	}
+
+	answer := &models.Answer{}
+	if err := database.GetCollection("answers").FindOne(
+		ctx,
+		bson.M{"messageId": messageID, "ownerId": access.ownerID},
+	).Decode(answer); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// answerTarget resolves the caller and the message in the :id parameter,
+// answering the request itself when either is missing.
+func answerTarget(c *fiber.Ctx) (*messageAccess, *models.Message, error) {
+	access, err := messageAccessFor(c)
+	if err != nil {
+		return nil, nil, utils.ErrorResponse(c, 400, "Bad Request")
+	}
+	messageId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, nil, utils.ErrorResponse(c, 400, "Invalid message id")
+	}
+	message, err := access.get(c.Context(), messageId)
+	if err == errMessageNotFound {
+		return nil, nil, utils.ErrorResponse(c, 404, "This message does not exist")
+	}
+	if err != nil {
+		return nil, nil, utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	return access, message, nil
+}
+
+// GetAnswer godoc
+// @Summary Get Answer
+// @Description Get the authenticated user's answer to one of their messages
+// @Tags Answers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.APIResponse{data=models.Answer} "Answer"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Message or answer not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/answer [get]
+func GetAnswer(c *fiber.Ctx) error {
+	access, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+
+	answer := models.Answer{}
+	err = database.GetCollection("answers").FindOne(
+		c.Context(),
+		bson.M{"messageId": message.ID, "ownerId": access.ownerID},
+	).Decode(&answer)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This message has no answer")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", answer)
+}
+
+// AnswerWithText godoc
+// @Summary Answer With Text
+// @Description Set a text answer (max 2000 characters) to one of the authenticated user's messages, replacing any earlier answer. Set publish to also show it on the public answers feed.
+// @Tags Answers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Param answerData body models.TextAnswerRequestDTO true "Answer text"
+// @Success 200 {object} utils.APIResponse{data=models.Answer} "Answer saved"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 404 {object} utils.APIResponse "Message not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/answer/text [put]
+func AnswerWithText(c *fiber.Ctx) error {
+	access, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+
+	requestData := models.TextAnswerRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	text := strings.TrimSpace(requestData.Text)
+	if text == "" || utf8.RuneCountInString(text) > maxAnswerTextLen {
+		return utils.ErrorResponse(c, 400, "text is required and must be at most 2000 characters")
+	}
+
+	answer, err := saveAnswer(c.Context(), access, message.ID, models.MessageTypeText, &models.TextPayload{Body: text}, nil, requestData.Publish)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Answer saved", answer)
+}
+
+// AnswerWithAudio godoc
+// @Summary Answer With Audio
+// @Description Record an audio answer to one of the authenticated user's messages, voice-filtered like audio messages, replacing any earlier answer. Set publish to also show it on the public answers feed.
+// @Tags Answers
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Param voice formData string true "Voice filter option"
+// @Param file formData file true "Audio file"
+// @Param publish formData bool false "Publish the answer"
+// @Success 200 {object} utils.APIResponse{data=models.Answer} "Answer saved"
+// @Failure 400 {object} map[string]string "Invalid request or file upload error"
+// @Failure 404 {object} utils.APIResponse "Message not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /message/{id}/answer/audio [put]
+func AnswerWithAudio(c *fiber.Ctx) error {
+	access, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+	publish := false
+	if raw := c.FormValue("publish"); raw != "" {
+		if publish, err = strconv.ParseBool(raw); err != nil {
+			return utils.ErrorResponse(c, 400, "publish must be true or false")
+		}
+	}
+
+	uploadResult, err := uploadVoiceAudio(c)
+	if err != nil {
+		return audioErrorResponse(c, err)
+	}
+
+	audio := &models.AudioPayload{URL: uploadResult.SecureURL, PublicID: uploadResult.PublicID}
+	answer, err := saveAnswer(c.Context(), access, message.ID, models.MessageTypeAudio, nil, audio, publish)
+	if err != nil {
+		if queueErr := jobs.EnqueueMediaDeletions(c.Context(), []string{uploadResult.PublicID}, "video", ""); queueErr != nil {
+			log.Printf("could not queue orphaned audio %s: %v", uploadResult.PublicID, queueErr)
+		}
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Answer saved", answer)
+}
+
+// setAnswerPublished publishes or unpublishes the answer to the message in
+// :id.
+func setAnswerPublished(c *fiber.Ctx, publish bool) error {
+	access, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"publishedAt": ""}}
+	if publish {
+		update = bson.M{"$min": bson.M{"publishedAt": time.Now()}}
+	}
+	answer := models.Answer{}
+	err = database.GetCollection("answers").FindOneAndUpdate(
+		c.Context(),
+		bson.M{"messageId": message.ID, "ownerId": access.ownerID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&answer)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This message has no answer")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	if publish {
+		return utils.SuccessResponse(c, 200, "Answer published", answer)
+	}
+	return utils.SuccessResponse(c, 200, "Answer unpublished", answer)
+}
+
+// PublishAnswer godoc
+// @Summary Publish Answer
+// @Description Show the answer and the message it replies to on the public answers feed
+// @Tags Answers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.APIResponse{data=models.Answer} "Answer published"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Message or answer not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/answer/publish [post]
+func PublishAnswer(c *fiber.Ctx) error {
+	return setAnswerPublished(c, true)
+}
+
+// UnpublishAnswer godoc
+// @Summary Unpublish Answer
+// @Description Take the answer off the public answers feed. It is kept and can be published again.
+// @Tags Answers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.APIResponse{data=models.Answer} "Answer unpublished"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Message or answer not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/answer/unpublish [post]
+func UnpublishAnswer(c *fiber.Ctx) error {
+	return setAnswerPublished(c, false)
+}
+
+// DeleteAnswer godoc
+// @Summary Delete Answer
+// @Description Delete the answer to one of the authenticated user's messages, and its audio
+// @Tags Answers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.APIResponse "Answer deleted"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Message or answer not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/answer [delete]
+func DeleteAnswer(c *fiber.Ctx) error {
+	access, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+
+	answer := models.Answer{}
+	err = database.GetCollection("answers").FindOneAndDelete(
+		c.Context(),
+		bson.M{"messageId": message.ID, "ownerId": access.ownerID},
+	).Decode(&answer)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This message has no answer")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if answer.Audio != nil && answer.Audio.PublicID != "" {
+		if err := jobs.EnqueueMediaDeletions(c.Context(), []string{answer.Audio.PublicID}, "video", ""); err != nil {
+			log.Printf("could not queue answer audio %s: %v", answer.Audio.PublicID, err)
+		}
+		jobs.TriggerMediaCleanup()
+	}
+
+	return utils.SuccessResponse(c, 200, "Answer deleted", nil)
+}
+
+// GetPublicAnswers godoc
+// @Summary Public Answers Feed
+// @Description Published answers of the user behind a share link, each with the anonymous message it replies to, most recently published first. Pass nextCursor as cursor for the next page.
+// @Tags Profile
+// @Produce json
+// @Param username path string true "Username from a share link"
+// @Param limit query int false "Page size, 1-100 (default 20)"
+// @Param cursor query string false "nextCursor from the previous page"
+// @Success 200 {object} utils.APIResponse{data=models.AnswerFeedResponse} "A page of answers"
+// @Success 301 "Redirect to the current username"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 404 {object} utils.APIResponse "User does not exist"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /u/{username}/answers [get]
+func GetPublicAnswers(c *fiber.Ctx) error {
+	user, isAlias, err := findUserByUsername(c.Context(), c.Params("username"))
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "User does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if isAlias {
+		location := "/u/" + url.PathEscape(user.Username) + "/answers"
+		if query := string(c.Request().URI().QueryString()); query != "" {
+			location += "?" + query
+		}
+		return c.Redirect(location, fiber.StatusMovedPermanently)
+	}
+
+	limit, sort, after, err := pageQuery(c, "publishedAt")
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	// Answers to trashed messages drop off the feed until restored
+	filters := []bson.M{{
+		"ownerId":          user.ID,
+		"publishedAt":      bson.M{"$exists": true},
+		"messageDeletedAt": bson.M{"$exists": false},
+	}}
+	if after != nil {
+		filters = append(filters, after)
+	}
+
+	// One extra document tells us whether there is a next page
+	cursor, err := database.GetCollection("answers").Find(
+		c.Context(),
+		bson.M{"$and": filters},
+		options.Find().SetSort(sort).SetLimit(limit+1),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	answers := make([]models.Answer, 0, limit)
+	if err := cursor.All(c.Context(), &answers); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	feed := models.AnswerFeedResponse{Answers: make([]models.PublicAnswerResponse, 0, len(answers))}
+	if int64(len(answers)) > limit {
+		answers = answers[:limit]
+		last := answers[limit-1]
+		feed.NextCursor = utils.EncodeCursor(*last.PublishedAt, last.ID)
+	}
+	if len(answers) == 0 {
+		return utils.SuccessResponse(c, 200, "", feed)
+	}
+
+	messageIDs := make([]primitive.ObjectID, 0, len(answers))
+	for _, answer := range answers {
+		messageIDs = append(messageIDs, answer.MessageID)
+	}
+	messageCursor, err := database.GetCollection("messages").Find(
+		c.Context(),
+		bson.M{"_id": bson.M{"$in": messageIDs}, "ownerId": user.ID, "deletedAt": bson.M{"$exists": false}},
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	messages := make([]models.Message, 0, len(messageIDs))
+	if err := messageCursor.All(c.Context(), &messages); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	byID := make(map[primitive.ObjectID]*models.Message, len(messages))
+	for i := range messages {
+		byID[messages[i].ID] = &messages[i]
+	}
+
+	for i := range answers {
+		if message, ok := byID[answers[i].MessageID]; ok {
+			feed.Answers = append(feed.Answers, models.AnswerToPublicAnswerResponse(&answers[i], message))
+		}
+	}
+
+	return utils.SuccessResponse(c, 200, "", feed)
+}
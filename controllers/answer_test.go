@@ -0,0 +1,58 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPublicAnswersSkipTrashedMessages(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("uma", "", "correct horse")
+	token := s.login("uma", "correct horse")
+
+	// Published oldest first, so the feed lists them newest first
+	messages := make([]models.Message, 3)
+	for i := range messages {
+		messages[i] = models.NewTextMessage(owner.ID, primitive.NilObjectID, fmt.Sprintf("question %d", i))
+		_, err := database.GetCollection("messages").InsertOne(context.Background(), messages[i])
+		require.NoError(t, err)
+		status, res := s.do(http.MethodPut, "/message/"+messages[i].ID.Hex()+"/answer/text", token, models.TextAnswerRequestDTO{Text: "answer", Publish: true})
+		require.Equal(t, 200, status, res.Message)
+	}
+
+	feed := func() []string {
+		status, res := s.do(http.MethodGet, "/u/uma/answers?limit=1", "", nil)
+		require.Equal(t, 200, status, res.Message)
+		page := models.AnswerFeedResponse{}
+		require.NoError(t, json.Unmarshal(res.Data, &page))
+		questions := make([]string, 0, len(page.Answers))
+		for _, answer := range page.Answers {
+			questions = append(questions, answer.Question.Text.Body)
+		}
+		if page.NextCursor != "" {
+			questions = append(questions, "more")
+		}
+		return questions
+	}
+	require.Equal(t, []string{"question 2", "more"}, feed())
+
+	// With the two newest trashed, the first page still has an answer on it
+	for _, message := range messages[1:] {
+		status, res := s.do(http.MethodDelete, "/message/delete-message/"+message.ID.Hex(), token, nil)
+		require.Equal(t, 201, status, res.Message)
+	}
+	assert.Equal(t, []string{"question 0"}, feed())
+
+	status, res := s.do(http.MethodPost, "/message/trash/"+messages[2].ID.Hex()+"/restore", token, nil)
+	require.Equal(t, 201, status, res.Message)
+	assert.Equal(t, []string{"question 2", "more"}, feed())
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/gofiber/fiber/v2"
+)
+
+// uploadVoiceAudio runs the "file" form upload through the voice filter
+// named by the "voice" form value and stores the result on Cloudinary.
+// Audio messages and audio answers both go through it. Errors are
+// *fiber.Error carrying the status to answer with.
+func uploadVoiceAudio(c *fiber.Ctx) (*uploader.UploadResult, error) {
+	// Get uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, fiber.NewError(400, "No file uploaded")
+	}
+
+	// Get filter settings
+	filters := utils.GetFilterSetting(c.FormValue("voice"))
+	if filters == "" {
+		return nil, fiber.NewError(400, "Invalid voice option")
+	}
+
+	// Create temp files
+	tempDir := os.TempDir()
+	timestamp := time.Now().UnixNano()
+	tempInputPath := filepath.Join(tempDir, fmt.Sprintf("input_%d.mp3", timestamp))
+	tempOutputPath := filepath.Join(tempDir, fmt.Sprintf("output_%d.mp3", timestamp))
+
+	// Cleanup
+	defer os.Remove(tempInputPath)
+	defer os.Remove(tempOutputPath)
+
+	// Save uploaded file to temp
+	if err := c.SaveFile(file, tempInputPath); err != nil {
+		return nil, fiber.NewError(500, "Failed to save uploaded file")
+	}
+
+	// Run FFmpeg
+	args := []string{
+		"-i", tempInputPath,
+		"-af", filters,
+		"-c:a", "libmp3lame",
+		"-b:a", "128k",
+		"-ac", "1",
+		"-f", "mp3",
+		"-y",
+		tempOutputPath,
+	}
+
+	cmd := exec.Command("ffmpeg", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("ffmpeg failed: %v: %s", err, output)
+		return nil, fiber.NewError(500, "Error processing audio")
+	}
+
+	openedFile, err := os.Open(tempOutputPath)
+	if err != nil {
+		return nil, fiber.NewError(500, "Failed to open uploaded file")
+	}
+	defer openedFile.Close()
+
+	// Upload to Cloudinary
+	uploadResult, err := config.Cloud.Upload.Upload(c.Context(), openedFile, uploader.UploadParams{
+		ResourceType: "video",
+		Folder:       "Voxa_audio",
+	})
+	if err != nil {
+		log.Printf("Cloudinary upload failed: %v", err)
+		return nil, fiber.NewError(500, "Error uploading to Cloudinary")
+	}
+	if uploadResult.Error.Message != "" {
+		log.Printf("Cloudinary upload failed: %s", uploadResult.Error.Message)
+		return nil, fiber.NewError(500, "Error uploading to Cloudinary")
+	}
+
+	return uploadResult, nil
+}
+
+// audioErrorResponse answers with the status and message of an error from
+// uploadVoiceAudio.
+func audioErrorResponse(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{"message": fiberErr.Message})
+	}
+	return c.Status(500).JSON(fiber.Map{"message": "Error processing audio"})
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxBulkMessages caps how many messages one bulk request touches.
+const maxBulkMessages = 500
+
+// bulkChange returns the state an action puts messages in, or nil for
+// trash and delete, which take them out of the inbox instead.
+func bulkChange(action string) (bson.M, error) {
+	switch action {
+	case models.BulkActionRead:
+		return bson.M{"isOpened": true}, nil
+	case models.BulkActionUnread:
+		return bson.M{"isOpened": false}, nil
+	case models.BulkActionStar:
+		return bson.M{"isStarred": true}, nil
+	case models.BulkActionUnstar:
+		return bson.M{"isStarred": false}, nil
+	case models.BulkActionTrash, models.BulkActionDelete:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("action must be read, unread, star, unstar, trash or delete")
+}
+
+// BulkMessages godoc
+// @Summary Bulk Message Action
+// @Description Mark read or unread, star or unstar, move to the trash, or permanently delete many inbox messages at once. Pass ids, or leave them out and select messages with the same query filters as the inbox listing (at least one is required). Up to 500 messages per request; a filter skips messages the action would not change, and hasMore says it matched more, so repeat it. Each message gets a status: ok, not_found or invalid_id.
+// @Tags MessageRoutes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param bulkData body models.BulkMessageRequestDTO true "Action and message IDs"
+// @Param unread query bool false "Only unread (true) or read (false) messages"
+// @Param starred query bool false "Only starred (true) or unstarred (false) messages"
+// @Param type query string false "text or audio"
+// @Param promptId query string false "Only messages sent to this prompt"
+// @Param from query string false "Created at or after, RFC 3339"
+// @Param to query string false "Created before, RFC 3339"
+// @Success 201 {object} utils.APIResponse{data=models.BulkMessageResponse} "Per-message results"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/bulk [post]
+func BulkMessages(c *fiber.Ctx) error {
+	access, err := messageAccessFor(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Bad Request")
+	}
+
+	requestData := models.BulkMessageRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	change, err := bulkChange(requestData.Action)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	filters, err := messageFilters(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	response := models.BulkMessageResponse{Results: make([]models.BulkMessageResult, 0)}
+	targets := make([]primitive.ObjectID, 0)
+
+	switch {
+	case len(requestData.IDs) > 0 && len(filters) > 0:
+		return utils.ErrorResponse(c, 400, "Pass either ids or filters, not both")
+
+	case len(requestData.IDs) > 0:
+		if len(requestData.IDs) > maxBulkMessages {
+			return utils.ErrorResponse(c, 400, fmt.Sprintf("At most %d ids per request", maxBulkMessages))
+		}
+		for _, raw := range requestData.IDs {
+			if id, err := primitive.ObjectIDFromHex(raw); err == nil {
+				targets = append(targets, id)
+			}
+		}
+
+	case len(filters) > 0:
+		// Messages the action would not change are skipped, so repeating the
+		// request always gets further. One extra document tells us whether
+		// the filter matched more
+		if change != nil {
+			filters = append(filters, bson.M{"$nor": []bson.M{change}})
+		}
+		found, err := access.list(
+			c.Context(),
+			filters,
+			options.Find().
+				SetProjection(bson.M{"_id": 1}).
+				SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+				SetLimit(maxBulkMessages+1),
+		)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		if len(found) > maxBulkMessages {
+			found = found[:maxBulkMessages]
+			response.HasMore = true
+		}
+		for _, message := range found {
+			targets = append(targets, message.ID)
+		}
+
+	default:
+		return utils.ErrorResponse(c, 400, "Pass ids or at least one filter")
+	}
+
+	// Anything outside the caller's inbox is simply not found. What was done
+	// is read back after the write rather than assumed from the targets
+	var done []primitive.ObjectID
+	byID := bson.M{"_id": bson.M{"$in": targets}}
+	switch {
+	case len(targets) == 0:
+	case requestData.Action == models.BulkActionDelete:
+		// A permanent delete also takes answers, replies and stored audio, so
+		// it goes through the same path as emptying the trash
+		done, err = access.delete(c.Context(), byID)
+	case requestData.Action == models.BulkActionTrash:
+		// Stored times have millisecond precision; anything trashed since
+		// then was trashed by this request
+		since := time.Now().Truncate(time.Millisecond)
+		if _, err = access.trash(c.Context(), byID); err == nil {
+			done, err = access.inTrash().listIDs(c.Context(), byID, bson.M{"deletedAt": bson.M{"$gte": since}})
+		}
+	default:
+		done, err = access.updateAll(c.Context(), targets, bson.M{"$set": change}, change)
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	succeeded := make(map[primitive.ObjectID]bool, len(done))
+	for _, id := range done {
+		succeeded[id] = true
+	}
+	status := func(id primitive.ObjectID) string {
+		if succeeded[id] {
+			return models.BulkResultOK
+		}
+		return models.BulkResultNotFound
+	}
+
+	// A filter reports every message it matched; ids are reported in the
+	// order they were asked for, once each
+	if len(requestData.IDs) == 0 {
+		for _, id := range targets {
+			response.Results = append(response.Results, models.BulkMessageResult{ID: id.Hex(), Status: status(id)})
+		}
+	}
+	reported := make(map[string]bool, len(requestData.IDs))
+	for _, raw := range requestData.IDs {
+		if reported[raw] {
+			continue
+		}
+		reported[raw] = true
+		result := models.BulkMessageResult{ID: raw, Status: models.BulkResultInvalidID}
+		if id, err := primitive.ObjectIDFromHex(raw); err == nil {
+			result.Status = status(id)
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return utils.SuccessResponse(c, 201, "Messages updated", response)
+}
@@ -0,0 +1,94 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bulk sends a bulk request and returns its decoded response.
+func (s *testServer) bulk(token, query string, request models.BulkMessageRequestDTO) models.BulkMessageResponse {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/message/bulk"+query, token, request)
+	require.Equal(s.t, 201, status, res.Message)
+	response := models.BulkMessageResponse{}
+	require.NoError(s.t, json.Unmarshal(res.Data, &response))
+	return response
+}
+
+func TestBulkFilterFinishes(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("xena", "", "correct horse")
+	token := s.login("xena", "correct horse")
+
+	// One more than a request handles, plus one that is read already
+	messages := make([]interface{}, 0, 502)
+	for i := 0; i < 502; i++ {
+		message := models.NewTextMessage(owner.ID, primitive.NilObjectID, "hello")
+		message.IsOpened = i == 0
+		messages = append(messages, message)
+	}
+	_, err := database.GetCollection("messages").InsertMany(context.Background(), messages)
+	require.NoError(t, err)
+
+	read := models.BulkMessageRequestDTO{Action: models.BulkActionRead}
+	response := s.bulk(token, "?starred=false", read)
+	assert.Len(t, response.Results, 500)
+	assert.True(t, response.HasMore)
+
+	response = s.bulk(token, "?starred=false", read)
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, models.BulkResultOK, response.Results[0].Status)
+	assert.False(t, response.HasMore)
+
+	response = s.bulk(token, "?starred=false", read)
+	assert.Empty(t, response.Results)
+
+	unread, err := database.GetCollection("messages").CountDocuments(context.Background(), bson.M{"ownerId": owner.ID, "isOpened": false})
+	require.NoError(t, err)
+	assert.Zero(t, unread)
+}
+
+func TestBulkResultsById(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("yusuf", "", "correct horse")
+	token := s.login("yusuf", "correct horse")
+
+	message := models.NewTextMessage(owner.ID, primitive.NilObjectID, "hello")
+	_, err := database.GetCollection("messages").InsertOne(context.Background(), message)
+	require.NoError(t, err)
+	missing := primitive.NewObjectID().Hex()
+	ids := []string{message.ID.Hex(), missing, "not-an-id", message.ID.Hex()}
+
+	statuses := func(response models.BulkMessageResponse) []string {
+		found := make([]string, 0, len(response.Results))
+		for _, result := range response.Results {
+			found = append(found, result.Status)
+		}
+		return found
+	}
+	want := []string{models.BulkResultOK, models.BulkResultNotFound, models.BulkResultInvalidID}
+
+	// Starring twice is fine; the message is starred either way
+	star := models.BulkMessageRequestDTO{Action: models.BulkActionStar, IDs: ids}
+	assert.Equal(t, want, statuses(s.bulk(token, "", star)))
+	assert.Equal(t, want, statuses(s.bulk(token, "", star)))
+
+	trash := models.BulkMessageRequestDTO{Action: models.BulkActionTrash, IDs: ids}
+	assert.Equal(t, want, statuses(s.bulk(token, "", trash)))
+	assert.Equal(t, models.BulkResultNotFound, statuses(s.bulk(token, "", trash))[0], "already in the trash")
+
+	status, res := s.do(http.MethodPost, "/message/trash/"+message.ID.Hex()+"/restore", token, nil)
+	require.Equal(t, 201, status, res.Message)
+	remove := models.BulkMessageRequestDTO{Action: models.BulkActionDelete, IDs: ids}
+	assert.Equal(t, want, statuses(s.bulk(token, "", remove)))
+	assert.Equal(t, models.BulkResultNotFound, statuses(s.bulk(token, "", remove))[0], "already deleted")
+}
@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// emailVerificationTTL is in minutes, matching utils.GeneratePurposeJWT.
+const emailVerificationTTL = 60 * 24
+
+// findUserByLogin looks a user up by verified email address when the
+// identifier contains an @, or else by username, ignoring case. Unverified
+// addresses never match, since anyone could have typed them in. Identifiers
+// with an @ that match no address are still tried as usernames, which
+// accounts from before the username policy may contain.
+func findUserByLogin(ctx context.Context, identifier string) (*models.User, error) {
+	userCollection := database.GetCollection("users")
+	user := &models.User{}
+	if strings.Contains(identifier, "@") {
+		if email, err := utils.NormalizeEmail(identifier); err == nil {
+			err := userCollection.FindOne(ctx, bson.M{"email": email, "emailVerified": true}).Decode(user)
+			if err == nil {
+				return user, nil
+			}
+			if err != mongo.ErrNoDocuments {
+				return nil, err
+			}
+		}
+	}
+
+	opts := options.FindOne().SetCollation(database.UsernameCollation)
+	if err := userCollection.FindOne(ctx, bson.M{"username": identifier}, opts).Decode(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// emailTaken reports whether an account other than userID has verified
+// email. Unverified addresses hold no claim on it; whoever verifies first
+// keeps it. Pass primitive.NilObjectID for new accounts.
+func emailTaken(ctx context.Context, email string, userID primitive.ObjectID) (bool, error) {
+	count, err := database.GetCollection("users").CountDocuments(
+		ctx, bson.M{"email": email, "emailVerified": true, "_id": bson.M{"$ne": userID}})
+	return count > 0, err
+}
+
+// sendEmailVerification mails a signed link that confirms user owns their
+// current address. The link stops working once the address changes.
+func sendEmailVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GeneratePurposeJWT("verify_email", map[string]interface{}{
+		"id":    user.ID.Hex(),
+		"email": user.Email,
+	}, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return config.Mailer.Send(ctx, utils.Mail{
+		To:      user.Email,
+		Subject: "Confirm your Voxa email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm that this is your email address by opening the link below. It expires in 24 hours.\n\n%s/verify-email?token=%s\n",
+			user.Username, config.AppURL(), url.QueryEscape(token),
+		),
+	})
+}
+
+// UpdateEmail godoc
+// @Summary Set Email Address
+// @Description Set or change the authenticated user's email address. The password is required, and the new address stays unverified until the emailed link is opened.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param emailData body models.UpdateEmailRequestDTO true "New email and current password"
+// @Success 200 {object} utils.APIResponse "Verification email sent"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Password is incorrect"
+// @Failure 429 {object} utils.APIResponse "Too many emails requested; see the Retry-After header"
+// @Failure 462 {object} utils.APIResponse "Email already exists"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Server busy"
+// @Router /account/email [put]
+func UpdateEmail(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.UpdateEmailRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.Email == "" || requestData.Password == "" {
+		return utils.ErrorResponse(c, 400, "email and password are required")
+	}
+	email, err := utils.NormalizeEmail(requestData.Email)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid email address")
+	}
+
+	userCollection := database.GetCollection("users")
+	user := models.User{}
+	if err := userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	passwordMatch, err := utils.VerifyPasswordSecure(user.Password, requestData.Password)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if !passwordMatch {
+		return utils.ErrorResponse(c, 401, "Password is incorrect")
+	}
+
+	taken, err := emailTaken(c.Context(), email, userId)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if taken {
+		return utils.ErrorResponse(c, 462, "Email already exists")
+	}
+
+	wait, err := accountMailWait(c.Context(), userId)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		return utils.ErrorResponse(c, 429, "Too many emails requested, please try again later")
+	}
+
+	if _, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId},
+		bson.M{"$set": bson.M{"email": email, "emailVerified": false}},
+	); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	user.Email = email
+	if err := sendEmailVerification(c.Context(), &user); err != nil {
+		log.Printf("verification email for %s failed: %v", user.ID.Hex(), err)
+	}
+
+	return utils.SuccessResponse(c, 200, "Verification email sent", nil)
+}
+
+// ResendEmailVerification godoc
+// @Summary Resend Verification Email
+// @Description Send a new verification link to the authenticated user's unverified email address
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse "Verification email sent"
+// @Failure 400 {object} utils.APIResponse "No unverified email on file"
+// @Failure 429 {object} utils.APIResponse "Too many emails requested; see the Retry-After header"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/email/resend-verification [post]
+func ResendEmailVerification(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if user.Email == "" || user.EmailVerified {
+		return utils.ErrorResponse(c, 400, "No unverified email on file")
+	}
+
+	wait, err := accountMailWait(c.Context(), userId)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		return utils.ErrorResponse(c, 429, "Too many emails requested, please try again later")
+	}
+
+	if err := sendEmailVerification(c.Context(), &user); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Verification email sent", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verify Email Address
+// @Description Confirm an email address using the token from the verification link
+// @Tags Account
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} utils.APIResponse "Email verified"
+// @Failure 400 {object} utils.APIResponse "Invalid or expired verification link"
+// @Failure 429 {object} utils.APIResponse "Too many invalid links; see the Retry-After header"
+// @Failure 462 {object} utils.APIResponse "Email already verified by another account"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/verify-email [get]
+func VerifyEmail(c *fiber.Ctx) error {
+	// Invalid links count against the address like failed logins
+	keys := []loginKey{ipLoginKey(c)}
+	if ok, err := guardAttempt(c, keys); !ok {
+		return err
+	}
+	invalidLink := func() error {
+		if wait, _ := recordLoginFailure(c.Context(), keys); wait > 0 {
+			setRetryAfter(c, wait)
+		}
+		return utils.ErrorResponse(c, 400, "Invalid or expired verification link")
+	}
+
+	claims, err := utils.ValidatePurposeJWT(c.Query("token"), "verify_email")
+	if err != nil {
+		return invalidLink()
+	}
+
+	id, _ := claims["id"].(string)
+	email, _ := claims["email"].(string)
+	userId, err := primitive.ObjectIDFromHex(id)
+	if err != nil || email == "" {
+		return invalidLink()
+	}
+
+	// Matching on the address makes links for a replaced address useless
+	result, err := database.GetCollection("users").UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId, "email": email},
+		bson.M{"$set": bson.M{"emailVerified": true}},
+	)
+	if err != nil {
+		// Several accounts may hold an address unverified; only one verifies it
+		if isDuplicateKey(err, "verified_email_unique") {
+			return utils.ErrorResponse(c, 462, "Email already verified by another account")
+		}
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if result.MatchedCount == 0 {
+		return invalidLink()
+	}
+
+	return utils.SuccessResponse(c, 200, "Email verified", nil)
+}
@@ -0,0 +1,62 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLoginByEmailNeedsVerifiedAddress(t *testing.T) {
+	s := newTestServer(t)
+	s.register("carol", "carol@example.com", "correct horse")
+
+	status, _ := s.do(http.MethodPost, "/account/login", "", models.UserRequestDTO{Username: "carol@example.com", Password: "correct horse"})
+	assert.Equal(t, 404, status, "unverified addresses can't log in")
+
+	status, _ = s.do(http.MethodGet, "/account/verify-email?token="+s.mailToken("carol@example.com", verifyEmailSubject), "", nil)
+	require.Equal(t, 200, status)
+	s.login("Carol@Example.com", "correct horse")
+}
+
+func TestOnlyVerifiedEmailsAreUnique(t *testing.T) {
+	s := newTestServer(t)
+
+	// Someone typing in another person's address doesn't block them
+	s.register("squatter", "dave@example.com", "correct horse")
+	s.register("dave", "dave@example.com", "correct horse")
+
+	status, _ := s.do(http.MethodGet, "/account/verify-email?token="+s.mailToken("dave@example.com", verifyEmailSubject), "", nil)
+	require.Equal(t, 200, status)
+	s.login("dave@example.com", "correct horse")
+
+	status, _ = s.do(http.MethodPost, "/account/register", "", models.UserRequestDTO{
+		Username: "latecomer",
+		Email:    "dave@example.com",
+		Password: "correct horse",
+	})
+	assert.Equal(t, 462, status, "verified addresses are taken")
+}
+
+func TestLoginFallsBackToUsernameWithAt(t *testing.T) {
+	s := newTestServer(t)
+
+	// Accounts from before the username policy may have an @ in the name
+	hashed, err := utils.HashPasswordSecure("correct horse")
+	require.NoError(t, err)
+	_, err = database.GetCollection("users").InsertOne(context.Background(), models.User{
+		ID:        primitive.NewObjectID(),
+		Username:  "erin@home",
+		Password:  hashed,
+		PushToken: []string{},
+	})
+	require.NoError(t, err)
+
+	s.login("erin@home", "correct horse")
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// dataExportCooldown is how long a finished or running export is handed
+	// back instead of starting another
+	dataExportCooldown = 24 * time.Hour
+	// dataExportLinkTTL is how long a download link from the API works
+	dataExportLinkTTL = time.Hour
+)
+
+// dataExportResponse adds a fresh signed download link to ready exports.
+func dataExportResponse(export *models.DataExport) models.DataExportResponse {
+	response := models.DataExportToDataExportResponse(export)
+	if export.Status != models.DataExportReady {
+		return response
+	}
+	link, expiresAt, err := jobs.SignedExportURL(export, dataExportLinkTTL)
+	if err != nil {
+		log.Printf("could not sign export %s: %v", export.ID.Hex(), err)
+		return response
+	}
+	response.DownloadURL = link
+	response.DownloadURLExpiresAt = &expiresAt
+	return response
+}
+
+// RequestDataExport godoc
+// @Summary Request Data Export
+// @Description Start building a ZIP of the authenticated user's profile, messages and audio. Poll the returned export for a download link; users with a verified email also get the link by mail. An export from the last 24 hours is returned instead of starting a new one.
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} utils.APIResponse{data=models.DataExportResponse} "Export queued or already available"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/exports [post]
+func RequestDataExport(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	exportCollection := database.GetCollection("data_exports")
+	existing := models.DataExport{}
+	err = exportCollection.FindOne(
+		c.Context(),
+		bson.M{
+			"userId":    userId,
+			"status":    bson.M{"$ne": models.DataExportFailed},
+			"createdAt": bson.M{"$gt": time.Now().Add(-dataExportCooldown)},
+		},
+		options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	).Decode(&existing)
+	if err == nil {
+		return utils.SuccessResponse(c, 202, "Export already requested", dataExportResponse(&existing))
+	}
+	if err != mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	export := models.DataExport{
+		ID:        primitive.NewObjectID(),
+		UserID:    userId,
+		Status:    models.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if _, err := exportCollection.InsertOne(c.Context(), export); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	jobs.TriggerDataExports()
+
+	return utils.SuccessResponse(c, 202, "Export queued", dataExportResponse(&export))
+}
+
+// GetDataExport godoc
+// @Summary Get Data Export
+// @Description Get the status of one of the authenticated user's exports. Ready exports include a download link valid for one hour.
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Export ID"
+// @Success 200 {object} utils.APIResponse{data=models.DataExportResponse} "Export status"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Export not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/exports/{id} [get]
+func GetDataExport(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	exportId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 404, "Export not found")
+	}
+
+	export := models.DataExport{}
+	err = database.GetCollection("data_exports").FindOne(
+		c.Context(), bson.M{"_id": exportId, "userId": userId},
+	).Decode(&export)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "Export not found")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", dataExportResponse(&export))
+}
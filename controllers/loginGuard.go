@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const securityEventListLimit = 50
+
+// loginPolicy decides how long a key has to wait after its failures: nothing
+// for the first few, then an exponential back-off, then a fixed lockout.
+type loginPolicy struct {
+	freeAttempts int
+	lockoutAfter int
+	lockout      time.Duration
+	window       time.Duration
+}
+
+var (
+	// accountIPLoginPolicy locks one address out of one account. Keying the
+	// lockout by address means guessing from elsewhere can't lock the owner
+	// out of their own account.
+	accountIPLoginPolicy = loginPolicy{freeAttempts: 3, lockoutAfter: 10, lockout: 15 * time.Minute, window: time.Hour}
+	// accountLoginPolicy slows guesses against one account spread over many
+	// addresses. It never waits more than a minute, so it can't be used to
+	// shut the owner out either.
+	accountLoginPolicy = loginPolicy{freeAttempts: 20, lockoutAfter: 1000, lockout: time.Minute, window: time.Hour}
+	// ipLoginPolicy is looser since many users can share one address
+	ipLoginPolicy = loginPolicy{freeAttempts: 20, lockoutAfter: 100, lockout: time.Hour, window: 2 * time.Hour}
+	// accountMailPolicy limits how often one account can be sent mail on
+	// request, such as reset links, so it can't be flooded
+	accountMailPolicy = loginPolicy{freeAttempts: 3, lockoutAfter: 10, lockout: time.Hour, window: time.Hour}
+)
+
+func (p loginPolicy) delay(failures int) time.Duration {
+	if failures >= p.lockoutAfter {
+		return p.lockout
+	}
+	if failures <= p.freeAttempts {
+		return 0
+	}
+	shift := failures - p.freeAttempts - 1
+	if shift > 30 {
+		shift = 30
+	}
+	delay := time.Second << uint(shift)
+	if delay > p.lockout {
+		return p.lockout
+	}
+	return delay
+}
+
+func (p loginPolicy) retryAfter(record utils.AttemptRecord, now time.Time) time.Duration {
+	wait := record.LastFailure.Add(p.delay(record.Failures)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+type loginKey struct {
+	key    string
+	policy loginPolicy
+	// account keys are cleared by a successful login. The IP key never is,
+	// so one known password can't wipe out an address's failures.
+	account bool
+}
+
+func accountLoginKey(userID primitive.ObjectID) string {
+	return "user:" + userID.Hex()
+}
+
+// ipLoginKey is the counter shared by every login attempt from c's address.
+func ipLoginKey(c *fiber.Ctx) loginKey {
+	return loginKey{key: "ip:" + c.IP(), policy: ipLoginPolicy}
+}
+
+// accountMailKey counts mail sent to userID's account on request.
+func accountMailKey(userID primitive.ObjectID) loginKey {
+	return loginKey{key: "mail:" + userID.Hex(), policy: accountMailPolicy}
+}
+
+// loginKeys returns the counters a login attempt is charged to, the one
+// that locks out first. Known accounts are keyed by ID so username and email
+// logins share one count.
+func loginKeys(c *fiber.Ctx, identifier string, user *models.User) []loginKey {
+	accountKey := "login:" + strings.ToLower(strings.TrimSpace(identifier))
+	if user != nil {
+		accountKey = accountLoginKey(user.ID)
+	}
+	return []loginKey{
+		{key: accountKey + "|ip:" + c.IP(), policy: accountIPLoginPolicy, account: true},
+		{key: accountKey, policy: accountLoginPolicy, account: true},
+		ipLoginKey(c),
+	}
+}
+
+// guardAttempt answers 429 with a Retry-After header when any of keys is
+// backing off. It returns whether the request may go ahead; when it may
+// not, the response has been written and err is what the handler returns.
+func guardAttempt(c *fiber.Ctx, keys []loginKey) (bool, error) {
+	wait, err := loginRetryAfter(c.Context(), keys)
+	if err != nil {
+		return false, utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		return false, utils.ErrorResponse(c, 429, "Too many attempts, please try again later")
+	}
+	return true, nil
+}
+
+// clearLoginFailures resets the account keys among keys after a login
+// succeeds.
+func clearLoginFailures(ctx context.Context, keys []loginKey) {
+	for _, k := range keys {
+		if !k.account {
+			continue
+		}
+		if err := config.LoginAttempts.Reset(ctx, k.key); err != nil {
+			log.Printf("could not reset failed logins for %s: %v", k.key, err)
+		}
+	}
+}
+
+// loginRetryAfter reports how long the caller must wait before any of keys
+// may try again.
+func loginRetryAfter(ctx context.Context, keys []loginKey) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for _, k := range keys {
+		record, err := config.LoginAttempts.Get(ctx, k.key)
+		if err != nil {
+			return 0, err
+		}
+		if w := k.policy.retryAfter(record, now); w > wait {
+			wait = w
+		}
+	}
+	return wait, nil
+}
+
+// recordLoginFailure charges a failure to every key and returns the wait it
+// imposes, plus whether the first key just reached its lockout.
+func recordLoginFailure(ctx context.Context, keys []loginKey) (time.Duration, bool) {
+	now := time.Now()
+	var wait time.Duration
+	locked := false
+	for i, k := range keys {
+		record, err := config.LoginAttempts.RecordFailure(ctx, k.key, k.policy.window)
+		if err != nil {
+			log.Printf("could not record failed login for %s: %v", k.key, err)
+			continue
+		}
+		if w := k.policy.retryAfter(record, now); w > wait {
+			wait = w
+		}
+		if i == 0 && record.Failures == k.policy.lockoutAfter {
+			locked = true
+		}
+	}
+	return wait, locked
+}
+
+// accountMailWait charges one requested mail to userID's account and returns
+// zero when it may be sent, or how long the account has to wait otherwise.
+func accountMailWait(ctx context.Context, userID primitive.ObjectID) (time.Duration, error) {
+	keys := []loginKey{accountMailKey(userID)}
+	wait, err := loginRetryAfter(ctx, keys)
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+	recordLoginFailure(ctx, keys)
+	return 0, nil
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds, rounding up.
+func setRetryAfter(c *fiber.Ctx, wait time.Duration) {
+	c.Set("Retry-After", fmt.Sprint(int64(math.Ceil(wait.Seconds()))))
+}
+
+// recordSecurityEvent stores an event for userID. Failures are logged
+// rather than returned so they never change the outcome of the request.
+func recordSecurityEvent(c *fiber.Ctx, userID primitive.ObjectID, eventType string) {
+	_, err := database.GetCollection("security_events").InsertOne(c.Context(), models.SecurityEvent{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Type:      eventType,
+		IP:        c.IP(),
+		UserAgent: c.Get("User-Agent"),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("could not record %s event for %s: %v", eventType, userID.Hex(), err)
+	}
+}
+
+// GetSecurityEvents godoc
+// @Summary List Security Events
+// @Description List recent security events on the authenticated user's account, such as failed logins and lockouts
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.SecurityEventResponse} "Security events, newest first"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/security-events [get]
+func GetSecurityEvents(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	cursor, err := database.GetCollection("security_events").Find(
+		c.Context(),
+		bson.M{"userId": userId},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(securityEventListLimit),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	events := make([]models.SecurityEventResponse, 0)
+	for cursor.Next(c.Context()) {
+		event := models.SecurityEvent{}
+		if err := cursor.Decode(&event); err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		events = append(events, models.SecurityEventToSecurityEventResponse(&event))
+	}
+
+	return utils.SuccessResponse(c, 200, "", events)
+}
@@ -0,0 +1,70 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginLockoutIsPerAddress(t *testing.T) {
+	s := newTestServer(t)
+	s.register("judy", "", "correct horse")
+
+	wrong := models.UserRequestDTO{Username: "judy", Password: "wrong password"}
+	status, _ := s.doFrom("198.51.100.7", http.MethodPost, "/account/login", "", wrong)
+	for i := 0; i < 4 && status != 429; i++ {
+		status, _ = s.doFrom("198.51.100.7", http.MethodPost, "/account/login", "", wrong)
+	}
+	require.Equal(t, 429, status, "the guessing address backs off")
+
+	// The owner, elsewhere, is not locked out by someone else's guesses
+	status, res := s.doFrom("203.0.113.9", http.MethodPost, "/account/login", "", models.UserRequestDTO{Username: "judy", Password: "correct horse"})
+	assert.Equal(t, 201, status, res.Message)
+}
+
+func TestPasswordResetConfirmIsThrottled(t *testing.T) {
+	s := newTestServer(t)
+
+	status := 0
+	for i := 0; i < 25 && status != 429; i++ {
+		status, _ = s.do(http.MethodPost, "/account/password-reset/confirm", "", models.PasswordResetConfirmDTO{
+			Token:       "not-a-real-token",
+			NewPassword: "battery staple",
+		})
+	}
+	assert.Equal(t, 429, status)
+}
+
+func TestVerifyEmailIsThrottled(t *testing.T) {
+	s := newTestServer(t)
+
+	status := 0
+	for i := 0; i < 25 && status != 429; i++ {
+		status, _ = s.do(http.MethodGet, "/account/verify-email?token=not-a-real-token", "", nil)
+	}
+	assert.Equal(t, 429, status)
+}
+
+func TestPasswordResetMailIsLimited(t *testing.T) {
+	s := newTestServer(t)
+	s.register("ken", "ken@example.com", "correct horse")
+	status, _ := s.do(http.MethodGet, "/account/verify-email?token="+s.mailToken("ken@example.com", verifyEmailSubject), "", nil)
+	require.Equal(t, 200, status)
+
+	for i := 0; i < 10; i++ {
+		status, _ := s.do(http.MethodPost, "/account/password-reset/request", "", models.PasswordResetRequestDTO{Username: "ken"})
+		require.Equal(t, 200, status, "the response never changes")
+	}
+
+	resets := 0
+	for _, mail := range s.mailer.Sent() {
+		if mail.Subject == passwordResetSubject {
+			resets++
+		}
+	}
+	// The fourth mail starts the back-off, which holds back the rest
+	assert.Equal(t, 4, resets)
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errMessageNotFound = errors.New("message not found")
+
+// messageAccess is the signed-in user's view of the messages collection.
+// Every query it runs is limited to their own inbox, so another inbox's
+// message IDs behave as if they did not exist. Message handlers must go
+// through it rather than query the collection directly.
+//
+// It sees either the inbox or, through inTrash, the trash; never both.
+type messageAccess struct {
+	ownerID primitive.ObjectID
+	trashed bool
+}
+
+// messageAccessFor scopes to the caller set by RequireAuth.
+func messageAccessFor(c *fiber.Ctx) (*messageAccess, error) {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return nil, err
+	}
+	return &messageAccess{ownerID: userId}, nil
+}
+
+// inTrash is the same caller's view of their trash.
+func (a *messageAccess) inTrash() *messageAccess {
+	return &messageAccess{ownerID: a.ownerID, trashed: true}
+}
+
+// scope ANDs conditions with the ownership and trash checks.
+func (a *messageAccess) scope(conditions ...bson.M) bson.M {
+	owned := bson.M{"ownerId": a.ownerID, "deletedAt": bson.M{"$exists": a.trashed}}
+	return bson.M{"$and": append([]bson.M{owned}, conditions...)}
+}
+
+// list returns the caller's messages matching conditions.
+func (a *messageAccess) list(ctx context.Context, conditions []bson.M, opts *options.FindOptions) ([]models.Message, error) {
+	cursor, err := database.GetCollection("messages").Find(ctx, a.scope(conditions...), opts)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]models.Message, 0)
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// get returns one of the caller's messages.
+func (a *messageAccess) get(ctx context.Context, id primitive.ObjectID) (*models.Message, error) {
+	message := &models.Message{}
+	err := database.GetCollection("messages").FindOne(ctx, a.scope(bson.M{"_id": id})).Decode(message)
+	if err == mongo.ErrNoDocuments {
+		return nil, errMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// update applies update to one of the caller's messages, if it also
+// matches conditions, and returns the result.
+func (a *messageAccess) update(ctx context.Context, id primitive.ObjectID, update bson.M, conditions ...bson.M) (*models.Message, error) {
+	message := &models.Message{}
+	err := database.GetCollection("messages").FindOneAndUpdate(
+		ctx,
+		a.scope(append([]bson.M{{"_id": id}}, conditions...)...),
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(message)
+	if err == mongo.ErrNoDocuments {
+		return nil, errMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// updateAll applies update to the caller's messages in ids and returns
+// those that match applied afterwards, i.e. the ones update now holds for.
+func (a *messageAccess) updateAll(ctx context.Context, ids []primitive.ObjectID, update bson.M, applied bson.M) ([]primitive.ObjectID, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	byID := bson.M{"_id": bson.M{"$in": ids}}
+	if _, err := database.GetCollection("messages").UpdateMany(ctx, a.scope(byID), update); err != nil {
+		return nil, err
+	}
+	return a.listIDs(ctx, byID, applied)
+}
+
+// listIDs returns the IDs of the caller's messages matching conditions.
+func (a *messageAccess) listIDs(ctx context.Context, conditions ...bson.M) ([]primitive.ObjectID, error) {
+	found, err := a.list(ctx, conditions, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(found))
+	for _, message := range found {
+		ids = append(ids, message.ID)
+	}
+	return ids, nil
+}
+
+// trash moves the caller's inbox messages matching conditions to the trash
+// and reports how many went. Their answers are flagged so the public feed
+// can leave them out without looking at the messages.
+func (a *messageAccess) trash(ctx context.Context, conditions ...bson.M) (int64, error) {
+	var trashed int64
+	err := database.RunInTransaction(ctx, func(ctx context.Context) error {
+		deletedAt := time.Now()
+
+		// Only the messages with an answer need their IDs looked up
+		answered, err := database.GetCollection("answers").Distinct(
+			ctx,
+			"messageId",
+			bson.M{"ownerId": a.ownerID, "messageDeletedAt": bson.M{"$exists": false}},
+		)
+		if err != nil {
+			return err
+		}
+		if len(answered) > 0 {
+			answeredTrashed, err := database.GetCollection("messages").Distinct(
+				ctx,
+				"_id",
+				a.scope(append([]bson.M{{"_id": bson.M{"$in": answered}}}, conditions...)...),
+			)
+			if err != nil {
+				return err
+			}
+			if len(answeredTrashed) > 0 {
+				_, err = database.GetCollection("answers").UpdateMany(
+					ctx,
+					bson.M{"ownerId": a.ownerID, "messageId": bson.M{"$in": answeredTrashed}},
+					bson.M{"$set": bson.M{"messageDeletedAt": deletedAt}},
+				)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		result, err := database.GetCollection("messages").UpdateMany(
+			ctx,
+			a.scope(conditions...),
+			bson.M{"$set": bson.M{"deletedAt": deletedAt}},
+		)
+		if err != nil {
+			return err
+		}
+		trashed = result.ModifiedCount
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return trashed, nil
+}
+
+// trashOne is trash for a single message ID.
+func (a *messageAccess) trashOne(ctx context.Context, id primitive.ObjectID) error {
+	trashed, err := a.trash(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if trashed == 0 {
+		return errMessageNotFound
+	}
+	return nil
+}
+
+// restore moves one of the caller's trashed messages back to the inbox,
+// unless it is already due to be purged, and puts its answer back on the
+// public feed if it was published.
+func (a *messageAccess) restore(ctx context.Context, id primitive.ObjectID) (*models.Message, error) {
+	var message *models.Message
+	err := database.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		message, err = a.inTrash().update(ctx, id, bson.M{"$unset": bson.M{"deletedAt": ""}}, bson.M{
+			"deletedAt": bson.M{"$gt": time.Now().Add(-jobs.MessageTrashRetention)},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = database.GetCollection("answers").UpdateOne(
+			ctx,
+			bson.M{"ownerId": a.ownerID, "messageId": id},
+			bson.M{"$unset": bson.M{"messageDeletedAt": ""}},
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// delete permanently removes the caller's messages matching conditions
+// together with their stored audio, and returns the IDs of those that went.
+func (a *messageAccess) delete(ctx context.Context, conditions ...bson.M) ([]primitive.ObjectID, error) {
+	var deleted []primitive.ObjectID
+	err := database.RunInTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		deleted, err = jobs.DeleteMessages(ctx, a.scope(conditions...))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(deleted) > 0 {
+		jobs.TriggerMediaCleanup()
+	}
+	return deleted, nil
+}
+
+// deleteOne is delete for a single message ID.
+func (a *messageAccess) deleteOne(ctx context.Context, id primitive.ObjectID) error {
+	deleted, err := a.delete(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return errMessageNotFound
+	}
+	return nil
+}
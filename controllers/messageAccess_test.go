@@ -0,0 +1,154 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ownedDocuments returns everything ownerID has in the collections message
+// routes write to, so a test can tell whether any of it changed.
+func ownedDocuments(t *testing.T, ownerID primitive.ObjectID) map[string][]bson.M {
+	t.Helper()
+	documents := make(map[string][]bson.M)
+	for _, name := range []string{"messages", "answers", "message_replies"} {
+		cursor, err := database.GetCollection(name).Find(
+			context.Background(),
+			bson.M{"ownerId": ownerID},
+			options.Find().SetSort(bson.M{"_id": 1}),
+		)
+		require.NoError(t, err)
+		found := make([]bson.M, 0)
+		require.NoError(t, cursor.All(context.Background(), &found))
+		documents[name] = found
+	}
+	return documents
+}
+
+func TestMessageRoutesHideOtherInboxes(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("olivia", "", "correct horse")
+	s.register("peter", "", "correct horse")
+	token := s.login("peter", "correct horse")
+
+	ctx := context.Background()
+	inbox := models.NewTextMessage(owner.ID, primitive.NilObjectID, "hello")
+	inbox.SenderTokenHash = "sender-token-hash"
+	trashed := models.NewTextMessage(owner.ID, primitive.NilObjectID, "old news")
+	deletedAt := time.Now()
+	trashed.DeletedAt = &deletedAt
+	_, err := database.GetCollection("messages").InsertMany(ctx, []interface{}{inbox, trashed})
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = database.GetCollection("answers").InsertOne(ctx, models.Answer{
+		ID:          primitive.NewObjectID(),
+		MessageID:   inbox.ID,
+		OwnerID:     owner.ID,
+		Type:        models.MessageTypeText,
+		Text:        &models.TextPayload{Body: "thanks"},
+		PublishedAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	require.NoError(t, err)
+	_, err = database.GetCollection("message_replies").InsertOne(ctx, models.MessageReply{
+		ID:        primitive.NewObjectID(),
+		MessageID: inbox.ID,
+		OwnerID:   owner.ID,
+		Author:    models.ReplyAuthorOwner,
+		Body:      "who is this?",
+		CreatedAt: now,
+	})
+	require.NoError(t, err)
+
+	before := ownedDocuments(t, owner.ID)
+	starred := true
+
+	t.Run("routes by id", func(t *testing.T) {
+		tests := []struct {
+			method string
+			path   string
+			body   interface{}
+		}{
+			{http.MethodPatch, "/message/mark-as-read/" + inbox.ID.Hex(), nil},
+			{http.MethodPatch, "/message/star-message/" + inbox.ID.Hex(), models.MessageMarkAsRead{State: &starred}},
+			{http.MethodDelete, "/message/delete-message/" + inbox.ID.Hex(), nil},
+			{http.MethodGet, "/message/" + inbox.ID.Hex() + "/answer", nil},
+			{http.MethodPut, "/message/" + inbox.ID.Hex() + "/answer/text", models.TextAnswerRequestDTO{Text: "not yours", Publish: true}},
+			{http.MethodPut, "/message/" + inbox.ID.Hex() + "/answer/audio", nil},
+			{http.MethodPost, "/message/" + inbox.ID.Hex() + "/answer/publish", nil},
+			{http.MethodPost, "/message/" + inbox.ID.Hex() + "/answer/unpublish", nil},
+			{http.MethodDelete, "/message/" + inbox.ID.Hex() + "/answer", nil},
+			{http.MethodGet, "/message/" + inbox.ID.Hex() + "/thread", nil},
+			{http.MethodPost, "/message/" + inbox.ID.Hex() + "/thread/replies", models.ThreadReplyRequestDTO{Text: "not yours"}},
+			{http.MethodPost, "/message/trash/" + trashed.ID.Hex() + "/restore", nil},
+			{http.MethodDelete, "/message/trash/" + trashed.ID.Hex(), nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+				status, res := s.do(tt.method, tt.path, token, tt.body)
+				assert.Equal(t, 404, status, res.Message)
+				assert.Equal(t, before, ownedDocuments(t, owner.ID))
+			})
+		}
+	})
+
+	t.Run("bulk by id", func(t *testing.T) {
+		for _, action := range []string{
+			models.BulkActionRead,
+			models.BulkActionUnread,
+			models.BulkActionStar,
+			models.BulkActionUnstar,
+			models.BulkActionTrash,
+			models.BulkActionDelete,
+		} {
+			status, res := s.do(http.MethodPost, "/message/bulk", token, models.BulkMessageRequestDTO{
+				Action: action,
+				IDs:    []string{inbox.ID.Hex(), trashed.ID.Hex()},
+			})
+			require.Equal(t, 201, status, res.Message)
+			response := models.BulkMessageResponse{}
+			require.NoError(t, json.Unmarshal(res.Data, &response))
+			assert.Equal(t, []models.BulkMessageResult{
+				{ID: inbox.ID.Hex(), Status: models.BulkResultNotFound},
+				{ID: trashed.ID.Hex(), Status: models.BulkResultNotFound},
+			}, response.Results, action)
+			assert.Equal(t, before, ownedDocuments(t, owner.ID), action)
+		}
+	})
+
+	t.Run("routes over the whole inbox", func(t *testing.T) {
+		tests := []struct {
+			method string
+			path   string
+			body   interface{}
+		}{
+			{http.MethodDelete, "/message/delete-all-messages", nil},
+			{http.MethodDelete, "/message/trash", nil},
+			{http.MethodPost, "/message/bulk?unread=true", models.BulkMessageRequestDTO{Action: models.BulkActionRead}},
+			{http.MethodPost, "/message/bulk?unread=true", models.BulkMessageRequestDTO{Action: models.BulkActionDelete}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+				status, res := s.do(tt.method, tt.path, token, tt.body)
+				assert.Equal(t, 201, status, res.Message)
+				assert.Equal(t, before, ownedDocuments(t, owner.ID))
+			})
+		}
+
+		status, res := s.do(http.MethodGet, "/message/trash", token, nil)
+		require.Equal(t, 200, status, res.Message)
+		assert.NotContains(t, string(res.Data), trashed.ID.Hex())
+	})
+}
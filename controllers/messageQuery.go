@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultMessagePageSize = 20
+	maxMessagePageSize     = 100
+)
+
+// queryBool reads an optional true/false query parameter.
+func queryBool(c *fiber.Ctx, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+	return &value, nil
+}
+
+// queryTime reads an optional RFC 3339 query parameter.
+func queryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+	}
+	return &value, nil
+}
+
+// messageFilters turns the inbox query parameters into conditions to AND
+// with the owner filter: unread, starred, type (text or audio), promptId
+// and a from (inclusive) / to (exclusive) date range.
+func messageFilters(c *fiber.Ctx) ([]bson.M, error) {
+	filters := make([]bson.M, 0)
+
+	unread, err := queryBool(c, "unread")
+	if err != nil {
+		return nil, err
+	}
+	if unread != nil {
+		filters = append(filters, bson.M{"isOpened": !*unread})
+	}
+
+	starred, err := queryBool(c, "starred")
+	if err != nil {
+		return nil, err
+	}
+	if starred != nil {
+		filters = append(filters, bson.M{"isStarred": *starred})
+	}
+
+	switch messageType := c.Query("type"); messageType {
+	case "":
+	case models.MessageTypeText, models.MessageTypeAudio:
+		filters = append(filters, bson.M{"type": messageType})
+	default:
+		return nil, fmt.Errorf("type must be text or audio")
+	}
+
+	if raw := c.Query("promptId"); raw != "" {
+		promptID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return nil, fmt.Errorf("promptId is not a valid id")
+		}
+		filters = append(filters, bson.M{"promptId": promptID})
+	}
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	if from != nil {
+		filters = append(filters, bson.M{"createdAt": bson.M{"$gte": *from}})
+	}
+	if to != nil {
+		filters = append(filters, bson.M{"createdAt": bson.M{"$lt": *to}})
+	}
+
+	return filters, nil
+}
+
+// messagePage reads the paging parameters of the inbox listing.
+func messagePage(c *fiber.Ctx) (limit int64, sort bson.D, after bson.M, err error) {
+	return pageQuery(c, "createdAt")
+}
+
+// pageQuery reads limit, order (desc by default) and cursor for a listing
+// ordered by the time field. It returns the sort to use and, for later
+// pages, a condition that resumes after the cursor.
+func pageQuery(c *fiber.Ctx, field string) (limit int64, sort bson.D, after bson.M, err error) {
+	limit = defaultMessagePageSize
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 1 || limit > maxMessagePageSize {
+			return 0, nil, nil, fmt.Errorf("limit must be between 1 and %d", maxMessagePageSize)
+		}
+	}
+
+	direction, op := -1, "$lt"
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		direction, op = 1, "$gt"
+	default:
+		return 0, nil, nil, fmt.Errorf("order must be asc or desc")
+	}
+	// _id breaks ties between documents from the same millisecond
+	sort = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+
+	if raw := c.Query("cursor"); raw != "" {
+		at, id, err := utils.DecodeCursor(raw)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		after = bson.M{"$or": []bson.M{
+			{field: bson.M{op: at}},
+			{field: at, "_id": bson.M{op: id}},
+		}}
+	}
+
+	return limit, sort, after, nil
+}
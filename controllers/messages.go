@@ -8,27 +8,28 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/Investorharry19/voxa-golang-server/config"
 	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
 	"github.com/Investorharry19/voxa-golang-server/models"
 	"github.com/Investorharry19/voxa-golang-server/utils"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // AddTextMessage godoc
 // @Summary Add Text Message
-// @Description Send a text message from a user
+// @Description Send a text message from a user. The response carries a senderToken that lets the sender follow the private thread on the message; it is shown only once.
 // @Tags MessageRoutes
 // @Accept json
 // @Produce json
-// @Param messageData body models.TextMessageRequestSwagger true "Text message data"
+// @Param messageData body models.TextMessageRequestDTO true "Text message data"
 // @Success 201 {object} map[string]interface{} "Message sent"
 // @Failure 400 {object} map[string]string "Bad Request"
-// @Failure 404 {object} map[string]string "User does not exist"
+// @Failure 404 {object} map[string]string "User or prompt does not exist"
+// @Failure 410 {object} map[string]string "Prompt is archived"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /message/send/text-message [post]
 func AddTextMessage(c *fiber.Ctx) error {
@@ -40,218 +41,157 @@ func AddTextMessage(c *fiber.Ctx) error {
 	if requestData.OwnerUsername == "" || requestData.MessageText == "" {
 		return utils.ErrorResponse(c, 400, "ownerusername and message text are required")
 	}
-	requestData.ID = primitive.NewObjectID()
-	requestData.Type = "text"
-	requestData.CreatedAt = time.Now()
-
-	userCollection := database.GetCollection("users")
-	user := models.User{}
-	if err := userCollection.FindOne(c.Context(), bson.M{"username": requestData.OwnerUsername}).Decode(&user); err != nil {
+	user, _, err := findUserByUsername(c.Context(), requestData.OwnerUsername)
+	if err != nil {
 		return utils.ErrorResponse(c, 404, "User does not exist")
 	}
+	prompt, err := messagePrompt(c.Context(), user.ID, requestData.PromptID)
+	if err == errPromptNotFound {
+		return utils.ErrorResponse(c, 404, "This prompt does not exist")
+	}
+	if err == errPromptArchived {
+		return utils.ErrorResponse(c, 410, "This prompt is no longer taking messages")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	newMessage := models.NewTextMessage(user.ID, prompt.ID, requestData.MessageText)
+	senderToken, err := issueSenderToken(&newMessage)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
 	messageCollection := database.GetCollection("messages")
-	res, err := messageCollection.InsertOne(c.Context(), requestData)
+	res, err := messageCollection.InsertOne(c.Context(), newMessage)
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Internal server error")
 	}
 
-	return utils.SuccessResponse(c, 201, "message sent", res)
+	return utils.SuccessResponse(c, 201, "message sent", fiber.Map{
+		"InsertedID":  res.InsertedID,
+		"senderToken": senderToken,
+	})
 }
 
 // SendAudioMessage godoc
 // @Summary Send Audio Message
-// @Description Upload and send an audio message for a user
+// @Description Upload and send an audio message for a user. The response carries a senderToken that lets the sender follow the private thread on the message; it is shown only once.
 // @Tags MessageRoutes
 // @Accept mpfd
 // @Produce json
 // @Param ownerUsername formData string true "Owner username"
+// @Param promptId formData string false "Prompt to file the message under (default prompt if empty)"
 // @Param voice formData string true "Voice filter option"
 // @Param file formData file true "Audio file"
 // @Success 200 {object} map[string]string "Audio message uploaded successfully"
 // @Failure 400 {object} map[string]string "Invalid request or file upload error"
-// @Failure 404 {object} map[string]string "User not found"
+// @Failure 404 {object} map[string]string "User or prompt not found"
+// @Failure 410 {object} map[string]string "Prompt is archived"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /message/send/audio-message [post]
 func SendAudioMessage(c *fiber.Ctx) error {
 
 	// Get form data
 	ownerUsername := c.FormValue("ownerUsername")
-	voice := c.FormValue("voice")
 
-	userCollection := database.GetCollection("users")
 	// Find user
-	user := models.User{}
-	err := userCollection.FindOne(c.Context(), bson.M{"username": ownerUsername}).Decode(&user)
+	user, _, err := findUserByUsername(c.Context(), ownerUsername)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return c.Status(404).JSON(fiber.Map{"message": "No user with this username"})
 		}
 		return c.Status(500).JSON(fiber.Map{"message": "Database error"})
 	}
-
-	// Get uploaded file
-	file, err := c.FormFile("file")
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"message": "No file uploaded"})
-	}
-
-	// Get filter settings
-	filters := utils.GetFilterSetting(voice)
-	if filters == "" {
-		return c.Status(400).JSON(fiber.Map{"message": "Invalid voice option"})
-	}
-
-	// Create temp files
-	tempDir := os.TempDir()
-	timestamp := time.Now().UnixNano()
-	tempInputPath := filepath.Join(tempDir, fmt.Sprintf("input_%d.mp3", timestamp))
-	tempOutputPath := filepath.Join(tempDir, fmt.Sprintf("output_%d.mp3", timestamp))
-
-	// Cleanup
-	defer os.Remove(tempInputPath)
-	defer os.Remove(tempOutputPath)
-
-	// Save uploaded file to temp
-	if err := c.SaveFile(file, tempInputPath); err != nil {
-		return c.Status(500).JSON(fiber.Map{"message": "Failed to save uploaded file"})
-	}
-
-	// Run FFmpeg
-	// Run FFmpeg
-	args := []string{
-		"-i", tempInputPath,
-		"-af", filters,
-		"-c:a", "libmp3lame",
-		"-b:a", "128k",
-		"-ac", "1",
-		"-f", "mp3",
-		"-y",
-		tempOutputPath,
-	}
-
-	fmt.Println("FFmpeg args:", args)
-	fmt.Println("Filters:", filters)
-
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
-
-	// Always print output, even on success
-	fmt.Println("FFmpeg output:", string(output))
-
-	if err != nil {
-		fmt.Printf("FFmpeg error: %s\n", string(output))
-		return c.Status(500).JSON(fiber.Map{"message": "Error processing audio"})
-	}
-	// After FFmpeg runs
-	inputInfo, _ := os.Stat(tempInputPath)
-	outputInfo, _ := os.Stat(tempOutputPath)
-
-	fmt.Println("Input file size:", inputInfo.Size())
-	fmt.Println("Output file size:", outputInfo.Size())
-
-	if err != nil {
-		fmt.Printf("FFmpeg error: %s\n", string(output))
-		return c.Status(500).JSON(fiber.Map{"message": "Error processing audio"})
+	prompt, err := messagePrompt(c.Context(), user.ID, c.FormValue("promptId"))
+	if err == errPromptNotFound {
+		return c.Status(404).JSON(fiber.Map{"message": "No prompt with this id"})
 	}
-
-	// Check if file exists and has content
-	fileInfo, err := os.Stat(tempOutputPath)
-	if err != nil {
-		fmt.Println("Output file error:", err)
-	} else {
-		fmt.Println("Output file size:", fileInfo.Size())
+	if err == errPromptArchived {
+		return c.Status(410).JSON(fiber.Map{"message": "This prompt is no longer taking messages"})
 	}
-
-	openedFile, err := os.Open(tempOutputPath)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to open uploaded file"})
+		return c.Status(500).JSON(fiber.Map{"message": "Database error"})
 	}
-	defer openedFile.Close()
 
-	// Upload to Cloudinary
-	uploadResult, err := config.Cloud.Upload.Upload(c.Context(), openedFile, uploader.UploadParams{
-		ResourceType: "video",
-		Folder:       "Voxa_audio",
-	})
-	fmt.Println(uploadResult.SecureURL)
-	fmt.Println(uploadResult.PublicID)
+	uploadResult, err := uploadVoiceAudio(c)
 	if err != nil {
-		fmt.Printf("Cloudinary upload error: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"message": "Error uploading to Cloudinary"})
+		return audioErrorResponse(c, err)
 	}
 
 	// Save message to database
-	newMessage := models.AudioMessageRequestDTO{
-		ID:            primitive.NewObjectID(),
-		OwnerUsername: ownerUsername,
-		AudioUrl:      uploadResult.SecureURL,
-		PublicId:      uploadResult.PublicID,
-		CreatedAt:     time.Now(),
-		Type:          "audio",
+	newMessage := models.NewAudioMessage(user.ID, prompt.ID, uploadResult.SecureURL, uploadResult.PublicID)
+	senderToken, err := issueSenderToken(&newMessage)
+	if err == nil {
+		messageCollection := database.GetCollection("messages")
+		_, err = messageCollection.InsertOne(c.Context(), newMessage)
 	}
-
-	messageCollection := database.GetCollection("messages")
-	_, err = messageCollection.InsertOne(c.Context(), newMessage)
 	if err != nil {
+		// Nothing references the upload, so don't leave it behind
+		if queueErr := jobs.EnqueueMediaDeletions(c.Context(), []string{uploadResult.PublicID}, "video", ""); queueErr != nil {
+			log.Printf("could not queue orphaned audio %s: %v", uploadResult.PublicID, queueErr)
+		}
 		return c.Status(500).JSON(fiber.Map{"message": "Failed to save message"})
 	}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"message": "Failed to save media reference"})
-	}
-
 	return c.Status(200).JSON(fiber.Map{
 		"cloudinaryUrl": uploadResult.SecureURL,
+		"messageId":     newMessage.ID,
+		"senderToken":   senderToken,
 	})
 }
 
 // get all messages
 // GetAllMessages godoc
 // @Summary Get All Messages
-// @Description Retrieve all messages for the authenticated user
+// @Description Page through the authenticated user's messages, newest first by default. Pass nextCursor from a response as cursor to get the next page.
 // @Tags MessageRoutes
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Message "List of messages"
+// @Param limit query int false "Page size, 1-100 (default 20)"
+// @Param cursor query string false "nextCursor from the previous page"
+// @Param order query string false "desc (default) or asc by creation time"
+// @Param unread query bool false "Only unread (true) or read (false) messages"
+// @Param starred query bool false "Only starred (true) or unstarred (false) messages"
+// @Param type query string false "text or audio"
+// @Param promptId query string false "Only messages sent to this prompt"
+// @Param from query string false "Created at or after, RFC 3339"
+// @Param to query string false "Created before, RFC 3339"
+// @Success 200 {object} utils.APIResponse{data=models.MessagePageResponse} "A page of messages"
 // @Failure 400 {object} map[string]string "Bad Request"
-// @Failure 404 {object} map[string]string "No messages found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
 // @Router /message/get-messages [get]
 func GetAllMessages(c *fiber.Ctx) error {
-	id := fmt.Sprintf("%v", c.Locals("userId"))
-	fmt.Println(id)
-	userId, err := primitive.ObjectIDFromHex(id)
+	access, err := messageAccessFor(c)
 	if err != nil {
-		fmt.Println(err)
 		return utils.ErrorResponse(c, 400, "Bad Request")
 	}
-	userCollection := database.GetCollection("users")
 
-	user := models.User{}
-	err = userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user)
+	filters, err := messageFilters(c)
 	if err != nil {
-		log.Fatal(err)
-		return utils.ErrorResponse(c, 400, "Bad Request")
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	limit, sort, after, err := messagePage(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	if after != nil {
+		filters = append(filters, after)
 	}
-	messageCollection := database.GetCollection("messages")
-	messages := make([]models.Message, 0)
 
-	fmt.Println(user.Username)
-	cursor, err := messageCollection.Find(c.Context(), bson.M{"ownerusername": user.Username})
+	// One extra document tells us whether there is a next page
+	messages, err := access.list(c.Context(), filters, options.Find().SetSort(sort).SetLimit(limit+1))
 	if err != nil {
-		fmt.Println(err)
-		return utils.ErrorResponse(c, 404, "No user with this username")
+		return utils.ErrorResponse(c, 500, "Internal server error")
 	}
-	for cursor.Next(c.Context()) {
-		message := models.Message{}
-		if err := cursor.Decode(&message); err != nil {
-			return utils.ErrorResponse(c, 500, "INternal server error")
-		}
-		messages = append(messages, message)
+
+	page := models.MessagePageResponse{Messages: messages}
+	if int64(len(messages)) > limit {
+		page.Messages = messages[:limit]
+		last := page.Messages[limit-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
 	}
 
-	return utils.SuccessResponse(c, 201, "", messages)
+	return utils.SuccessResponse(c, 201, "", page)
 }
 
 // Mark as read
@@ -262,33 +202,33 @@ func GetAllMessages(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Message ID"
-// @Success 200 {object} map[string]interface{} "Message updated"
+// @Success 201 {object} utils.APIResponse{data=models.Message} "Message updated"
 // @Failure 400 {object} map[string]string "Invalid message ID or user ID"
+// @Failure 404 {object} map[string]string "Message not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
 // @Router /message/mark-as-read/{id} [patch]
 func MarkAsRead(c *fiber.Ctx) error {
-	id := fmt.Sprintf("%v", c.Locals("userId"))
-	_, err := primitive.ObjectIDFromHex(id)
+	access, err := messageAccessFor(c)
 	if err != nil {
 		return utils.ErrorResponse(c, 400, "")
 	}
-	messageId := c.Params("id")
-	messageObjectId, err := primitive.ObjectIDFromHex(messageId)
+	messageObjectId, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
-		return utils.ErrorResponse(c, 400, "Invalid user id")
+		return utils.ErrorResponse(c, 400, "Invalid message id")
 	}
-	messageCollection := database.GetCollection("messages")
-	update := bson.M{
-		"$set": bson.M{"isopened": true},
+
+	message, err := access.update(c.Context(), messageObjectId, bson.M{
+		"$set": bson.M{"isOpened": true},
+	})
+	if err == errMessageNotFound {
+		return utils.ErrorResponse(c, 404, "This message does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
 	}
-	result := messageCollection.FindOneAndUpdate(
-		c.Context(),
-		bson.M{"_id": messageObjectId},
-		update,
-	)
 
-	return utils.SuccessResponse(c, 201, "Message updated", result)
+	return utils.SuccessResponse(c, 201, "Message updated", message)
 
 }
 
@@ -301,22 +241,20 @@ func MarkAsRead(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Message ID"
 // @Param state body models.MessageMarkAsRead true "Star state"
-// @Success 200 {object} map[string]interface{} "Message updated"
+// @Success 201 {object} utils.APIResponse{data=models.Message} "Message updated"
 // @Failure 400 {object} map[string]string "Invalid message ID or request body"
 // @Failure 404 {object} map[string]string "Message not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
 // @Router /message/star-message/{id} [patch]
 func StarMessage(c *fiber.Ctx) error {
-	id := fmt.Sprintf("%v", c.Locals("userId"))
-	_, err := primitive.ObjectIDFromHex(id)
+	access, err := messageAccessFor(c)
 	if err != nil {
 		return utils.ErrorResponse(c, 400, "")
 	}
-	messageId := c.Params("id")
-	messageObjectId, err := primitive.ObjectIDFromHex(messageId)
+	messageObjectId, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
-		return utils.ErrorResponse(c, 400, "Invalid user id")
+		return utils.ErrorResponse(c, 400, "Invalid message id")
 	}
 	state := models.MessageMarkAsRead{}
 	if err := c.BodyParser(&state); err != nil {
@@ -326,95 +264,81 @@ func StarMessage(c *fiber.Ctx) error {
 	if state.State == nil {
 		return utils.ErrorResponse(c, 400, "starred state required")
 	}
-	messageCollection := database.GetCollection("messages")
-	update := bson.M{
-		"$set": bson.M{"isstarred": state.State},
-	}
-
-	message := models.Message{}
-	messageCollection.FindOne(c.Context(), bson.M{"_id": messageObjectId}).Decode(&message)
-
-	if message.OwnerUsername == "" {
-		return utils.ErrorResponse(c, 404, "This message does not exits")
 
+	message, err := access.update(c.Context(), messageObjectId, bson.M{
+		"$set": bson.M{"isStarred": *state.State},
+	})
+	if err == errMessageNotFound {
+		return utils.ErrorResponse(c, 404, "This message does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
 	}
-	result := messageCollection.FindOneAndUpdate(
-		c.Context(),
-		bson.M{"_id": messageObjectId},
-		update,
-	)
 
-	return utils.SuccessResponse(c, 201, "Message updated", result)
+	return utils.SuccessResponse(c, 201, "Message updated", message)
 
 }
 
 // delete one message
 // DeleteOneMessage godoc
 // @Summary Delete a Message
-// @Description Delete a specific message by the authenticated user
+// @Description Move one of the authenticated user's messages to the trash. It can be restored for 30 days, after which it and its stored audio are deleted for good.
 // @Tags MessageRoutes
 // @Accept json
 // @Produce json
 // @Param id path string true "Message ID"
-// @Success 200 {object} map[string]interface{} "Message deleted"
+// @Success 201 {object} utils.APIResponse "Message moved to trash"
 // @Failure 400 {object} map[string]string "Invalid message ID or user ID"
 // @Failure 404 {object} map[string]string "Message not found"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
-// @Router /message/delete-message{id} [delete]
+// @Router /message/delete-message/{id} [delete]
 func DeleteOneMessage(c *fiber.Ctx) error {
-	id := fmt.Sprintf("%v", c.Locals("userId"))
-	_, err := primitive.ObjectIDFromHex(id)
+	access, err := messageAccessFor(c)
 	if err != nil {
 		return utils.ErrorResponse(c, 400, "")
 	}
-	messageId := c.Params("id")
-	messageObjectId, err := primitive.ObjectIDFromHex(messageId)
+	messageObjectId, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
-		return utils.ErrorResponse(c, 400, "Invalid user id")
+		return utils.ErrorResponse(c, 400, "Invalid message id")
 	}
 
-	messageCollection := database.GetCollection("messages")
-
-	result := messageCollection.FindOneAndDelete(
-		c.Context(),
-		bson.M{"_id": messageObjectId},
-	)
+	err = access.trashOne(c.Context(), messageObjectId)
+	if err == errMessageNotFound {
+		return utils.ErrorResponse(c, 404, "This message does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
 
-	return utils.SuccessResponse(c, 201, "Message deleted", result)
+	return utils.SuccessResponse(c, 201, "Message moved to trash", nil)
 
 }
 
 // delete all message
 // DeleteAllMessages godoc
 // @Summary Delete All Messages
-// @Description Delete all messages of the authenticated user
+// @Description Move every message in the authenticated user's inbox to the trash. They can be restored for 30 days, after which they and their stored audio are deleted for good.
 // @Tags MessageRoutes
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]interface{} "All messages deleted"
+// @Success 201 {object} map[string]interface{} "All messages moved to trash"
 // @Failure 400 {object} map[string]string "Invalid user ID"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Security BearerAuth
 // @Router /message/delete-all-messages [delete]
 func DeleteAllMessages(c *fiber.Ctx) error {
-	id := fmt.Sprintf("%v", c.Locals("userId"))
-	objectId, err := primitive.ObjectIDFromHex(id)
+	access, err := messageAccessFor(c)
 	if err != nil {
-		return utils.ErrorResponse(c, 400, "")
+		return utils.ErrorResponse(c, 400, "Bad Request")
 	}
 
-	messageCollection := database.GetCollection("messages")
-
-	result, err := messageCollection.DeleteMany(
-		c.Context(),
-		bson.M{"ownerusername": objectId},
-	)
+	deleted, err := access.trash(c.Context())
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Internal server error")
 	}
 
-	return utils.SuccessResponse(c, 201, "Message deleted", result)
+	return utils.SuccessResponse(c, 201, "Messages moved to trash", fiber.Map{"deletedCount": deleted})
 
 }
 
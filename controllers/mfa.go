@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	totpIssuer         = "Voxa"
+	recoveryCodeCount  = 10
+	mfaPendingTokenTTL = 5 // minutes
+	// maxMFAAttempts is how many wrong codes one challenge survives
+	maxMFAAttempts = 5
+)
+
+// issueMFAChallenge returns the short-lived token a client trades, together
+// with a second factor, for real tokens at /account/login/mfa.
+func issueMFAChallenge(user *models.User) (models.MFAChallengeResponse, error) {
+	token, err := utils.GeneratePurposeJWT("mfa_pending", map[string]interface{}{
+		"id":  user.ID.Hex(),
+		"ver": user.TokenVersion,
+		"jti": primitive.NewObjectID().Hex(),
+	}, mfaPendingTokenTTL)
+	if err != nil {
+		return models.MFAChallengeResponse{}, err
+	}
+	return models.MFAChallengeResponse{MFARequired: true, MFAToken: token}, nil
+}
+
+// consumeSecondFactor checks a TOTP code or a recovery code for user and
+// marks it used. Both updates are conditional so a code can't be redeemed
+// twice, even by concurrent requests.
+func consumeSecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) (bool, error) {
+	userCollection := database.GetCollection("users")
+
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return false, nil
+		}
+		result, err := userCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.ID, "$or": bson.A{
+				bson.M{"totpLastStep": bson.M{"$exists": false}},
+				bson.M{"totpLastStep": bson.M{"$lt": step}},
+			}},
+			bson.M{"$set": bson.M{"totpLastStep": step}},
+		)
+		if err != nil {
+			return false, err
+		}
+		return result.ModifiedCount == 1, nil
+	}
+
+	if recoveryCode != "" {
+		hash := utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))
+		result, err := userCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.ID, "recoveryCodes": hash},
+			bson.M{"$pull": bson.M{"recoveryCodes": hash}},
+		)
+		if err != nil {
+			return false, err
+		}
+		return result.ModifiedCount == 1, nil
+	}
+
+	return false, nil
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP Enrollment
+// @Description Generate a TOTP secret and its otpauth:// provisioning URI (render it as a QR code). Two-factor is not active until the confirm step succeeds.
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=models.TOTPEnrollResponse} "Scan the provisioning URI"
+// @Failure 400 {object} utils.APIResponse "Two-factor already enabled"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/mfa/totp/enroll [post]
+func EnrollTOTP(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	userCollection := database.GetCollection("users")
+	user := models.User{}
+	if err := userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if user.MFAEnabled {
+		return utils.ErrorResponse(c, 400, "Two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if _, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId},
+		bson.M{"$set": bson.M{"totpPendingSecret": secret}},
+	); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Scan the provisioning URI, then confirm with a code", models.TOTPEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP Enrollment
+// @Description Activate two-factor authentication with a code from the authenticator app. Returns one-time recovery codes, which are shown only once.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param codeData body models.TOTPCodeRequestDTO true "Current TOTP code"
+// @Success 200 {object} utils.APIResponse{data=models.RecoveryCodesResponse} "Two-factor enabled"
+// @Failure 400 {object} utils.APIResponse "Invalid code or no enrollment in progress"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/mfa/totp/confirm [post]
+func ConfirmTOTP(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.TOTPCodeRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+
+	userCollection := database.GetCollection("users")
+	user := models.User{}
+	if err := userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if user.MFAEnabled || user.TOTPPendingSecret == "" {
+		return utils.ErrorResponse(c, 400, "No two-factor enrollment in progress")
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPPendingSecret, requestData.Code, time.Now(), 0)
+	if !ok {
+		return utils.ErrorResponse(c, 400, "Invalid code")
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	result, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId, "totpPendingSecret": user.TOTPPendingSecret},
+		bson.M{
+			"$set": bson.M{
+				"mfaEnabled":    true,
+				"totpSecret":    user.TOTPPendingSecret,
+				"totpLastStep":  step,
+				"recoveryCodes": hashes,
+			},
+			"$unset": bson.M{"totpPendingSecret": ""},
+		},
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if result.ModifiedCount == 0 {
+		return utils.ErrorResponse(c, 400, "No two-factor enrollment in progress")
+	}
+
+	return utils.SuccessResponse(c, 200, "Two-factor authentication enabled", models.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+// DisableTOTP godoc
+// @Summary Disable Two-Factor Authentication
+// @Description Turn off TOTP for the authenticated user. Requires the password and a TOTP or recovery code.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param disableData body models.TOTPDisableRequestDTO true "Password and second factor"
+// @Success 200 {object} utils.APIResponse "Two-factor disabled"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Invalid password or code"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Server busy"
+// @Router /account/mfa/totp/disable [post]
+func DisableTOTP(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.TOTPDisableRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.Password == "" || (requestData.Code == "" && requestData.RecoveryCode == "") {
+		return utils.ErrorResponse(c, 400, "password and a code or recoveryCode are required")
+	}
+
+	userCollection := database.GetCollection("users")
+	user := models.User{}
+	if err := userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if !user.MFAEnabled {
+		return utils.ErrorResponse(c, 400, "Two-factor authentication is not enabled")
+	}
+
+	passwordMatch, err := utils.VerifyPasswordSecure(user.Password, requestData.Password)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if !passwordMatch {
+		return utils.ErrorResponse(c, 401, "Invalid password or code")
+	}
+
+	ok, err := consumeSecondFactor(c.Context(), &user, requestData.Code, requestData.RecoveryCode)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if !ok {
+		return utils.ErrorResponse(c, 401, "Invalid password or code")
+	}
+
+	if _, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId},
+		bson.M{
+			"$set": bson.M{"mfaEnabled": false},
+			"$unset": bson.M{
+				"totpSecret":        "",
+				"totpPendingSecret": "",
+				"totpLastStep":      "",
+				"recoveryCodes":     "",
+			},
+		},
+	); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Two-factor authentication disabled", nil)
+}
+
+// LoginMFA godoc
+// @Summary Complete Two-Factor Login
+// @Description Exchange the mfaToken from /account/login and a TOTP or recovery code for an access token and refresh token
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param mfaData body models.MFALoginRequestDTO true "MFA token and second factor"
+// @Success 201 {object} utils.APIResponse{data=models.TokenPairResponse} "Logged In"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Invalid or expired code, or too many wrong codes for this mfaToken"
+// @Failure 429 {object} utils.APIResponse "Too many failed attempts; see the Retry-After header"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/login/mfa [post]
+func LoginMFA(c *fiber.Ctx) error {
+	requestData := models.MFALoginRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.MFAToken == "" || (requestData.Code == "" && requestData.RecoveryCode == "") {
+		return utils.ErrorResponse(c, 400, "mfaToken and a code or recoveryCode are required")
+	}
+
+	claims, err := utils.ValidatePurposeJWT(requestData.MFAToken, "mfa_pending")
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Invalid or expired code")
+	}
+	id, _ := claims["id"].(string)
+	jti, _ := claims["jti"].(string)
+	tokenVersion, _ := claims["ver"].(float64)
+	userId, err := primitive.ObjectIDFromHex(id)
+	if err != nil || jti == "" {
+		return utils.ErrorResponse(c, 401, "Invalid or expired code")
+	}
+
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Invalid or expired code")
+	}
+	// A password change or logout-all since the first step voids the challenge
+	if !user.MFAEnabled || user.TokenVersion != int(tokenVersion) {
+		return utils.ErrorResponse(c, 401, "Invalid or expired code")
+	}
+
+	// Wrong codes count against the account and address like wrong
+	// passwords, and each challenge is void after a few of them
+	keys := loginKeys(c, "", &user)
+	wait, err := loginRetryAfter(c.Context(), keys)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		return utils.ErrorResponse(c, 429, "Too many login attempts, please try again later")
+	}
+	challengeKey := "mfa:" + jti
+	challenge, err := config.LoginAttempts.Get(c.Context(), challengeKey)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if challenge.Failures >= maxMFAAttempts {
+		return utils.ErrorResponse(c, 401, "Too many wrong codes, please log in again")
+	}
+
+	ok, err := consumeSecondFactor(c.Context(), &user, requestData.Code, requestData.RecoveryCode)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if !ok {
+		if _, err := config.LoginAttempts.RecordFailure(c.Context(), challengeKey, mfaPendingTokenTTL*time.Minute); err != nil {
+			log.Printf("could not record failed code for %s: %v", user.ID.Hex(), err)
+		}
+		wait, locked := recordLoginFailure(c.Context(), keys)
+		recordSecurityEvent(c, user.ID, models.SecurityEventLoginFailed)
+		if locked {
+			recordSecurityEvent(c, user.ID, models.SecurityEventLoginLocked)
+		}
+		if wait > 0 {
+			setRetryAfter(c, wait)
+		}
+		return utils.ErrorResponse(c, 401, "Invalid or expired code")
+	}
+
+	clearLoginFailures(c.Context(), keys)
+
+	session, err := createSession(c, &user)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	tokens, err := issueTokenPair(c.Context(), &user, session.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Logged In", tokens)
+}
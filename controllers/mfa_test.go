@@ -0,0 +1,129 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// enableRecoveryCode turns two-factor on for user with a single recovery
+// code, which is simpler to drive from a test than a TOTP.
+func enableRecoveryCode(t *testing.T, user models.User, code string) {
+	t.Helper()
+	_, err := database.GetCollection("users").UpdateOne(context.Background(),
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{
+			"mfaEnabled":    true,
+			"recoveryCodes": []string{utils.HashToken(utils.NormalizeRecoveryCode(code))},
+		}},
+	)
+	require.NoError(t, err)
+}
+
+func (s *testServer) mfaChallenge(username, password string) string {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/account/login", "", models.UserRequestDTO{Username: username, Password: password})
+	require.Equal(s.t, 202, status, res.Message)
+	challenge := models.MFAChallengeResponse{}
+	require.NoError(s.t, json.Unmarshal(res.Data, &challenge))
+	return challenge.MFAToken
+}
+
+func TestLoginMFA(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("frank", "", "correct horse")
+	enableRecoveryCode(t, user, "aaaa-bbbb-cccc")
+
+	mfaToken := s.mfaChallenge("frank", "correct horse")
+	status, res := s.do(http.MethodPost, "/account/login/mfa", "", models.MFALoginRequestDTO{MFAToken: mfaToken, RecoveryCode: "aaaa-bbbb-cccc"})
+	require.Equal(t, 201, status, res.Message)
+
+	// Recovery codes work once
+	mfaToken = s.mfaChallenge("frank", "correct horse")
+	status, _ = s.do(http.MethodPost, "/account/login/mfa", "", models.MFALoginRequestDTO{MFAToken: mfaToken, RecoveryCode: "aaaa-bbbb-cccc"})
+	assert.Equal(t, 401, status)
+}
+
+// challengeAttempts only counts failures per MFA challenge, so tests can
+// exhaust a challenge without the account backing off first.
+type challengeAttempts struct {
+	utils.AttemptStore
+}
+
+func (s challengeAttempts) Get(ctx context.Context, key string) (utils.AttemptRecord, error) {
+	if !strings.HasPrefix(key, "mfa:") {
+		return utils.AttemptRecord{}, nil
+	}
+	return s.AttemptStore.Get(ctx, key)
+}
+
+func (s challengeAttempts) RecordFailure(ctx context.Context, key string, window time.Duration) (utils.AttemptRecord, error) {
+	if !strings.HasPrefix(key, "mfa:") {
+		return utils.AttemptRecord{}, nil
+	}
+	return s.AttemptStore.RecordFailure(ctx, key, window)
+}
+
+func TestLoginMFAVoidsChallengeAfterWrongCodes(t *testing.T) {
+	s := newTestServer(t)
+	config.LoginAttempts = challengeAttempts{utils.NewMemoryAttemptStore()}
+	user := s.register("grace", "", "correct horse")
+	enableRecoveryCode(t, user, "aaaa-bbbb-cccc")
+	mfaToken := s.mfaChallenge("grace", "correct horse")
+
+	for i := 0; i < 5; i++ {
+		status, _ := s.do(http.MethodPost, "/account/login/mfa", "", models.MFALoginRequestDTO{
+			MFAToken:     mfaToken,
+			RecoveryCode: fmt.Sprintf("wrong-code-%04d", i),
+		})
+		require.Equal(t, 401, status)
+	}
+
+	status, _ := s.do(http.MethodPost, "/account/login/mfa", "", models.MFALoginRequestDTO{MFAToken: mfaToken, RecoveryCode: "aaaa-bbbb-cccc"})
+	assert.Equal(t, 401, status, "the right code no longer works on this challenge")
+
+	stored := models.User{}
+	require.NoError(t, database.GetCollection("users").FindOne(context.Background(), bson.M{"_id": user.ID}).Decode(&stored))
+	assert.Len(t, stored.RecoveryCodes, 1, "the recovery code was not spent")
+
+	// A fresh challenge gets its own attempts
+	mfaToken = s.mfaChallenge("grace", "correct horse")
+	status, _ = s.do(http.MethodPost, "/account/login/mfa", "", models.MFALoginRequestDTO{MFAToken: mfaToken, RecoveryCode: "aaaa-bbbb-cccc"})
+	assert.Equal(t, 201, status)
+}
+
+func TestLoginMFAFailuresCountAsFailedLogins(t *testing.T) {
+	s := newTestServer(t)
+	user := s.register("heidi", "", "correct horse")
+	enableRecoveryCode(t, user, "aaaa-bbbb-cccc")
+	mfaToken := s.mfaChallenge("heidi", "correct horse")
+
+	for i := 0; i < 4; i++ {
+		status, _ := s.do(http.MethodPost, "/account/login/mfa", "", models.MFALoginRequestDTO{
+			MFAToken:     mfaToken,
+			RecoveryCode: fmt.Sprintf("wrong-code-%04d", i),
+		})
+		require.Equal(t, 401, status)
+	}
+
+	// The known password doesn't clear the failed codes
+	status, _ := s.do(http.MethodPost, "/account/login", "", models.UserRequestDTO{Username: "heidi", Password: "correct horse"})
+	assert.Equal(t, 429, status)
+
+	events, err := database.GetCollection("security_events").CountDocuments(context.Background(),
+		bson.M{"userId": user.ID, "type": models.SecurityEventLoginFailed})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, events)
+}
@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// oauthParam reads a callback parameter from the query string or, for
+// providers using response_mode=form_post, from the form body.
+func oauthParam(c *fiber.Ctx, key string) string {
+	if value := c.Query(key); value != "" {
+		return value
+	}
+	return c.FormValue(key)
+}
+
+// redirectOAuthResult sends the browser back to the client app with the
+// outcome in the URL fragment, which never reaches any server's logs.
+func redirectOAuthResult(c *fiber.Ctx, values url.Values) error {
+	return c.Redirect(config.OAuthClientRedirect()+"#"+values.Encode(), fiber.StatusFound)
+}
+
+func redirectOAuthError(c *fiber.Ctx, message string) error {
+	return redirectOAuthResult(c, url.Values{"error": {message}})
+}
+
+// generateOAuthUsername derives a free username from the identity's email or
+// name, adding a random numeric suffix.
+func generateOAuthUsername(ctx context.Context, identity *utils.OIDCIdentity) (string, error) {
+	seed := strings.ToLower(identity.Name)
+	if at := strings.Index(identity.Email, "@"); at > 0 {
+		seed = strings.ToLower(identity.Email[:at])
+	}
+	seed = strings.Trim(usernameUnsafeChars.ReplaceAllString(seed, "_"), "_")
+	if len(seed) < 3 {
+		seed = "voxa"
+	}
+	if len(seed) > 20 {
+		seed = seed[:20]
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate := fmt.Sprintf("%s_%04d", seed, suffix.Int64())
+
+		taken, err := usernameTaken(ctx, candidate, primitive.NilObjectID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a free username for %q", seed)
+}
+
+// resolveOAuthUser finds the Voxa account for identity, linking it to an
+// account with the same verified email or creating a new one.
+func resolveOAuthUser(ctx context.Context, provider string, identity *utils.OIDCIdentity) (*models.User, error) {
+	identityCollection := database.GetCollection("user_identities")
+	userCollection := database.GetCollection("users")
+
+	linked := models.UserIdentity{}
+	err := identityCollection.FindOne(ctx, bson.M{"provider": provider, "subject": identity.Subject}).Decode(&linked)
+	if err == nil {
+		user := &models.User{}
+		if err := userCollection.FindOne(ctx, bson.M{"_id": linked.UserID}).Decode(user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// Only link by email when both sides have proven they own the address
+	var user *models.User
+	email := ""
+	if identity.EmailVerified && identity.Email != "" {
+		email, _ = utils.NormalizeEmail(identity.Email)
+	}
+	if email != "" {
+		existing := &models.User{}
+		err := userCollection.FindOne(ctx, bson.M{"email": email, "emailVerified": true}).Decode(existing)
+		if err == nil {
+			user = existing
+		} else if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		username, err := generateOAuthUsername(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+		user = &models.User{
+			ID:        primitive.NewObjectID(),
+			Username:  username,
+			PushToken: make([]string, 0),
+		}
+		// No account has verified this address (that one would have been
+		// linked above), so the provider's verification claims it
+		if email != "" {
+			user.Email, user.EmailVerified = email, true
+		}
+		if _, err := userCollection.InsertOne(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = identityCollection.InsertOne(ctx, models.UserIdentity{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetOAuthProviders godoc
+// @Summary List Social Login Providers
+// @Description List the configured OpenID Connect providers
+// @Tags Account
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]string} "Provider names"
+// @Router /account/oauth/providers [get]
+func GetOAuthProviders(c *fiber.Ctx) error {
+	return utils.SuccessResponse(c, 200, "", utils.OIDCProviderNames())
+}
+
+// StartOAuthLogin godoc
+// @Summary Start Social Login
+// @Description Redirect to the provider's sign-in page using PKCE. The callback later redirects to the client app with tokens, an mfaToken or an error in the URL fragment.
+// @Tags Account
+// @Param provider path string true "Provider name, e.g. google or apple"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} utils.APIResponse "Unknown provider"
+// @Failure 502 {object} utils.APIResponse "Provider unavailable"
+// @Router /account/oauth/{provider} [get]
+func StartOAuthLogin(c *fiber.Ctx) error {
+	providerName := c.Params("provider")
+	provider, ok := utils.GetOIDCProvider(providerName)
+	if !ok {
+		return utils.ErrorResponse(c, 404, "Unknown provider")
+	}
+
+	state, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	nonce, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	verifier, challenge, err := utils.GeneratePKCE()
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Context(), state, nonce, challenge)
+	if err != nil {
+		log.Printf("oauth %s: %v", providerName, err)
+		return utils.ErrorResponse(c, 502, "Provider unavailable")
+	}
+
+	now := time.Now()
+	if _, err := database.GetCollection("oauth_states").InsertOne(c.Context(), models.OAuthState{
+		ID:           primitive.NewObjectID(),
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(oauthStateTTL),
+	}); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OAuthCallback godoc
+// @Summary Social Login Callback
+// @Description Redirect target for the provider. Validates the ID token, links or creates the Voxa account and redirects to the client app.
+// @Tags Account
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string true "State from the authorization request"
+// @Success 302 "Redirect to the client app"
+// @Router /account/oauth/{provider}/callback [get]
+func OAuthCallback(c *fiber.Ctx) error {
+	providerName := c.Params("provider")
+	provider, ok := utils.GetOIDCProvider(providerName)
+	if !ok {
+		return utils.ErrorResponse(c, 404, "Unknown provider")
+	}
+
+	state := oauthParam(c, "state")
+	if state == "" {
+		return redirectOAuthError(c, "invalid_request")
+	}
+
+	// Consume the state whatever happens next, so it can't be replayed
+	stored := models.OAuthState{}
+	err := database.GetCollection("oauth_states").FindOneAndDelete(c.Context(), bson.M{
+		"stateHash": utils.HashToken(state),
+		"provider":  providerName,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&stored)
+	if err != nil {
+		return redirectOAuthError(c, "invalid_state")
+	}
+
+	if providerError := oauthParam(c, "error"); providerError != "" {
+		return redirectOAuthError(c, providerError)
+	}
+	code := oauthParam(c, "code")
+	if code == "" {
+		return redirectOAuthError(c, "invalid_request")
+	}
+
+	rawIDToken, err := provider.Exchange(c.Context(), code, stored.CodeVerifier)
+	if err != nil {
+		log.Printf("oauth %s: %v", providerName, err)
+		return redirectOAuthError(c, "exchange_failed")
+	}
+	identity, err := provider.VerifyIDToken(c.Context(), rawIDToken, stored.Nonce)
+	if err != nil {
+		log.Printf("oauth %s: %v", providerName, err)
+		return redirectOAuthError(c, "invalid_id_token")
+	}
+
+	user, err := resolveOAuthUser(c.Context(), providerName, identity)
+	if err != nil {
+		log.Printf("oauth %s: %v", providerName, err)
+		return redirectOAuthError(c, "server_error")
+	}
+
+	if user.MFAEnabled {
+		challenge, err := issueMFAChallenge(user)
+		if err != nil {
+			return redirectOAuthError(c, "server_error")
+		}
+		return redirectOAuthResult(c, url.Values{
+			"mfaRequired": {"true"},
+			"mfaToken":    {challenge.MFAToken},
+		})
+	}
+
+	session, err := createSession(c, user)
+	if err != nil {
+		return redirectOAuthError(c, "server_error")
+	}
+	tokens, err := issueTokenPair(c.Context(), user, session.ID)
+	if err != nil {
+		return redirectOAuthError(c, "server_error")
+	}
+
+	return redirectOAuthResult(c, url.Values{
+		"token":        {tokens.Token},
+		"refreshToken": {tokens.RefreshToken},
+		"expiresIn":    {strconv.Itoa(tokens.ExpiresIn)},
+	})
+}
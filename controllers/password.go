@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	minPasswordLength = 8
+	passwordResetTTL  = 30 * time.Minute
+)
+
+// sendPasswordReset issues a reset token for user and mails the link.
+// Earlier unused tokens for the same user are discarded.
+func sendPasswordReset(ctx context.Context, user *models.User) error {
+	resetCollection := database.GetCollection("password_resets")
+	if _, err := resetCollection.DeleteMany(ctx, bson.M{"userId": user.ID, "usedAt": bson.M{"$exists": false}}); err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetTTL),
+	}
+	if _, err := resetCollection.InsertOne(ctx, reset); err != nil {
+		return err
+	}
+
+	return config.Mailer.Send(ctx, utils.Mail{
+		To:      user.Email,
+		Subject: "Reset your Voxa password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and works once.\n\n%s/reset-password?token=%s\n\nIf you didn't ask for this, you can ignore this email.\n",
+			user.Username, int(passwordResetTTL.Minutes()), config.AppURL(), token,
+		),
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change Password
+// @Description Change the authenticated user's password. Every other session is logged out and a fresh token is returned for this one.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param passwordData body models.ChangePasswordRequestDTO true "Old and new password"
+// @Success 200 {object} utils.APIResponse "Password changed"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Old password is incorrect"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Server busy"
+// @Router /account/change-password [post]
+func ChangePassword(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	sessionId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("sessionId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.ChangePasswordRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.OldPassword == "" || requestData.NewPassword == "" {
+		return utils.ErrorResponse(c, 400, "oldPassword and newPassword are required")
+	}
+	if len(requestData.NewPassword) < minPasswordLength {
+		return utils.ErrorResponse(c, 400, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+	}
+
+	userCollection := database.GetCollection("users")
+	user := models.User{}
+	if err := userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	passwordMatch, err := utils.VerifyPasswordSecure(user.Password, requestData.OldPassword)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if !passwordMatch {
+		return utils.ErrorResponse(c, 401, "Old password is incorrect")
+	}
+
+	hashed, err := utils.HashPasswordSecure(requestData.NewPassword)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	if _, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId},
+		bson.M{"$set": bson.M{"password_hash": hashed}},
+	); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	updatedUser, err := revokeOtherSessions(c.Context(), userId, sessionId)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	token, err := utils.GenerateJWT(utils.AccessClaims{
+		Email:        updatedUser.Email,
+		UserID:       updatedUser.ID.Hex(),
+		SessionID:    sessionId.Hex(),
+		TokenVersion: updatedUser.TokenVersion,
+		Role:         updatedUser.EffectiveRole(),
+	}, accessTokenTTL)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Password changed", struct {
+		Token string `json:"token"`
+	}{Token: token})
+}
+
+// RequestPasswordReset godoc
+// @Summary Request Password Reset
+// @Description Email a single-use password reset link to the account's verified address. Accepts a username or an email address. The response is the same whether or not the account exists.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param resetData body models.PasswordResetRequestDTO true "Account to reset"
+// @Success 200 {object} utils.APIResponse "Reset link sent if the account exists"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Router /account/password-reset/request [post]
+func RequestPasswordReset(c *fiber.Ctx) error {
+	requestData := models.PasswordResetRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.Username == "" {
+		return utils.ErrorResponse(c, 400, "username is required")
+	}
+
+	// Only verified addresses get reset links, so a mistyped or unconfirmed
+	// address never receives control of the account
+	user, err := findUserByLogin(c.Context(), requestData.Username)
+	if err == nil && user.Email != "" && user.EmailVerified {
+		// Past the limit the request is dropped silently, as the response
+		// must look the same either way
+		if wait, err := accountMailWait(c.Context(), user.ID); err != nil || wait > 0 {
+			log.Printf("password reset for %s not sent: rate limited", user.ID.Hex())
+		} else if err := sendPasswordReset(c.Context(), user); err != nil {
+			log.Printf("password reset for %s failed: %v", user.ID.Hex(), err)
+		}
+	}
+
+	// Don't reveal whether the account exists or has an address on file
+	return utils.SuccessResponse(c, 200, "If the account exists, a reset link has been sent", nil)
+}
+
+// ConfirmPasswordReset godoc
+// @Summary Confirm Password Reset
+// @Description Set a new password using a reset token. The token works once and every session of the account is logged out.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param resetData body models.PasswordResetConfirmDTO true "Reset token and new password"
+// @Success 200 {object} utils.APIResponse "Password reset"
+// @Failure 400 {object} utils.APIResponse "Invalid or expired reset token"
+// @Failure 429 {object} utils.APIResponse "Too many invalid tokens; see the Retry-After header"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Server busy"
+// @Router /account/password-reset/confirm [post]
+func ConfirmPasswordReset(c *fiber.Ctx) error {
+	requestData := models.PasswordResetConfirmDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.Token == "" || requestData.NewPassword == "" {
+		return utils.ErrorResponse(c, 400, "token and newPassword are required")
+	}
+	if len(requestData.NewPassword) < minPasswordLength {
+		return utils.ErrorResponse(c, 400, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+	}
+
+	// Invalid tokens count against the address like failed logins
+	keys := []loginKey{ipLoginKey(c)}
+	if ok, err := guardAttempt(c, keys); !ok {
+		return err
+	}
+
+	// Hash first so a busy hasher doesn't burn the single-use token
+	hashed, err := utils.HashPasswordSecure(requestData.NewPassword)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	now := time.Now()
+	reset := models.PasswordReset{}
+	err = database.GetCollection("password_resets").FindOneAndUpdate(
+		c.Context(),
+		bson.M{
+			"tokenHash": utils.HashToken(requestData.Token),
+			"usedAt":    bson.M{"$exists": false},
+			"expiresAt": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"usedAt": now}},
+	).Decode(&reset)
+	if err != nil {
+		if wait, _ := recordLoginFailure(c.Context(), keys); wait > 0 {
+			setRetryAfter(c, wait)
+		}
+		return utils.ErrorResponse(c, 400, "Invalid or expired reset token")
+	}
+
+	if _, err := database.GetCollection("users").UpdateOne(
+		c.Context(),
+		bson.M{"_id": reset.UserID},
+		bson.M{"$set": bson.M{"password_hash": hashed}},
+	); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	if err := revokeAllSessions(c.Context(), reset.UserID); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Password reset", nil)
+}
@@ -0,0 +1,81 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	verifyEmailSubject   = "Confirm your Voxa email address"
+	passwordResetSubject = "Reset your Voxa password"
+)
+
+func TestPasswordResetFlow(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice", "alice@example.com", "correct horse")
+
+	// Unverified addresses never get a reset link
+	status, _ := s.do(http.MethodPost, "/account/password-reset/request", "", models.PasswordResetRequestDTO{Username: "alice"})
+	require.Equal(t, 200, status)
+	for _, mail := range s.mailer.Sent() {
+		assert.NotEqual(t, passwordResetSubject, mail.Subject)
+	}
+
+	status, _ = s.do(http.MethodGet, "/account/verify-email?token="+s.mailToken("alice@example.com", verifyEmailSubject), "", nil)
+	require.Equal(t, 200, status)
+
+	status, _ = s.do(http.MethodPost, "/account/password-reset/request", "", models.PasswordResetRequestDTO{Username: "alice@example.com"})
+	require.Equal(t, 200, status)
+	token := s.mailToken("alice@example.com", passwordResetSubject)
+
+	oldSession := s.login("alice", "correct horse")
+
+	status, res := s.do(http.MethodPost, "/account/password-reset/confirm", "", models.PasswordResetConfirmDTO{Token: token, NewPassword: "battery staple"})
+	require.Equal(t, 200, status, res.Message)
+
+	status, _ = s.do(http.MethodGet, "/account/current-user", oldSession, nil)
+	assert.Equal(t, 401, status, "sessions from before the reset are logged out")
+	status, _ = s.do(http.MethodPost, "/account/login", "", models.UserRequestDTO{Username: "alice", Password: "correct horse"})
+	assert.Equal(t, 404, status)
+	s.login("alice", "battery staple")
+
+	// The token works once
+	status, _ = s.do(http.MethodPost, "/account/password-reset/confirm", "", models.PasswordResetConfirmDTO{Token: token, NewPassword: "another password"})
+	assert.Equal(t, 400, status)
+	s.login("alice", "battery staple")
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	s := newTestServer(t)
+	s.register("bob", "", "correct horse")
+	current := s.login("bob", "correct horse")
+	other := s.login("bob", "correct horse")
+
+	status, _ := s.do(http.MethodPost, "/account/change-password", current, models.ChangePasswordRequestDTO{
+		OldPassword: "wrong password",
+		NewPassword: "battery staple",
+	})
+	require.Equal(t, 401, status)
+
+	status, res := s.do(http.MethodPost, "/account/change-password", current, models.ChangePasswordRequestDTO{
+		OldPassword: "correct horse",
+		NewPassword: "battery staple",
+	})
+	require.Equal(t, 200, status, res.Message)
+	var changed struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(res.Data, &changed))
+
+	status, _ = s.do(http.MethodGet, "/account/current-user", other, nil)
+	assert.Equal(t, 401, status, "other sessions are logged out")
+	status, _ = s.do(http.MethodGet, "/account/current-user", changed.Token, nil)
+	assert.Equal(t, 201, status, "the session that changed the password stays in")
+
+	s.login("bob", "battery staple")
+}
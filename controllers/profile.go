@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxDisplayNameLen = 50
+	maxBioLen         = 280
+	maxInboxPromptLen = 120
+	maxAvatarSize     = 5 << 20
+)
+
+// avatarTypes are the image formats accepted as avatars, by sniffed type.
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+// profileField trims value and checks it against max characters.
+func profileField(value, name string, max int) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > max {
+		return "", fmt.Errorf("%s must be at most %d characters", name, max)
+	}
+	return value, nil
+}
+
+// GetPublicProfile godoc
+// @Summary Get Public Profile
+// @Description Public details of the recipient behind a share link: display name, bio, inbox prompt and avatar. Old usernames redirect to the current one.
+// @Tags Profile
+// @Produce json
+// @Param username path string true "Username from a share link"
+// @Success 200 {object} utils.APIResponse{data=models.PublicProfileResponse} "Public profile"
+// @Success 301 "Redirect to the current username"
+// @Failure 404 {object} utils.APIResponse "User does not exist"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /u/{username} [get]
+func GetPublicProfile(c *fiber.Ctx) error {
+	user, isAlias, err := findUserByUsername(c.Context(), c.Params("username"))
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "User does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if isAlias {
+		return c.Redirect("/u/"+url.PathEscape(user.Username), fiber.StatusMovedPermanently)
+	}
+
+	return utils.SuccessResponse(c, 200, "", models.UserToPublicProfileResponse(user))
+}
+
+// UpdateProfile godoc
+// @Summary Update Profile
+// @Description Set the display name (max 50 characters), bio (max 280) and inbox prompt (max 120) shown on the public profile. Omitted fields are left alone; empty strings clear them.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profileData body models.UpdateProfileRequestDTO true "Profile fields to change"
+// @Success 200 {object} utils.APIResponse{data=models.PublicProfileResponse} "Profile updated"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/profile [patch]
+func UpdateProfile(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.UpdateProfileRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	fields := []struct {
+		value *string
+		key   string
+		max   int
+	}{
+		{requestData.DisplayName, "displayName", maxDisplayNameLen},
+		{requestData.Bio, "bio", maxBioLen},
+		{requestData.InboxPrompt, "inboxPrompt", maxInboxPromptLen},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+		value, err := profileField(*field.value, field.key, field.max)
+		if err != nil {
+			return utils.ErrorResponse(c, 400, err.Error())
+		}
+		if value == "" {
+			unset[field.key] = ""
+		} else {
+			set[field.key] = value
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return utils.ErrorResponse(c, 400, "Nothing to update")
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	user := models.User{}
+	err = database.GetCollection("users").FindOneAndUpdate(
+		c.Context(),
+		bson.M{"_id": userId},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Profile updated", models.UserToPublicProfileResponse(&user))
+}
+
+// UploadAvatar godoc
+// @Summary Upload Avatar
+// @Description Replace the avatar shown on the public profile. Accepts JPEG, PNG, WebP or GIF up to 5 MB; it is cropped to a 400x400 square.
+// @Tags Profile
+// @Accept mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Avatar image"
+// @Success 200 {object} utils.APIResponse{data=models.PublicProfileResponse} "Avatar updated"
+// @Failure 400 {object} utils.APIResponse "Missing, oversized or unsupported image"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/profile/avatar [put]
+func UploadAvatar(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "No file uploaded")
+	}
+	if file.Size > maxAvatarSize {
+		return utils.ErrorResponse(c, 400, "Avatar must be at most 5 MB")
+	}
+	openedFile, err := file.Open()
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	defer openedFile.Close()
+
+	// Trust the bytes, not the declared content type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(openedFile, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return utils.ErrorResponse(c, 400, "Unsupported image type")
+	}
+	if !avatarTypes[http.DetectContentType(head[:n])] {
+		return utils.ErrorResponse(c, 400, "Unsupported image type")
+	}
+	if _, err := openedFile.Seek(0, io.SeekStart); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	uploadResult, err := config.Cloud.Upload.Upload(c.Context(), openedFile, uploader.UploadParams{
+		ResourceType:   "image",
+		Folder:         "Voxa_avatars",
+		Transformation: "c_fill,g_face,w_400,h_400",
+	})
+	if err == nil && uploadResult.Error.Message != "" {
+		err = fmt.Errorf("%s", uploadResult.Error.Message)
+	}
+	if err != nil {
+		log.Printf("avatar upload for %s failed: %v", userId.Hex(), err)
+		return utils.ErrorResponse(c, 500, "Error uploading avatar")
+	}
+
+	previous := models.User{}
+	err = database.GetCollection("users").FindOneAndUpdate(
+		c.Context(),
+		bson.M{"_id": userId},
+		bson.M{"$set": bson.M{"avatarUrl": uploadResult.SecureURL, "avatarPublicId": uploadResult.PublicID}},
+	).Decode(&previous)
+	if err != nil {
+		// Nobody references the new upload, so it goes straight away
+		if queueErr := jobs.EnqueueMediaDeletions(c.Context(), []string{uploadResult.PublicID}, "image", ""); queueErr != nil {
+			log.Printf("could not queue orphaned avatar %s: %v", uploadResult.PublicID, queueErr)
+		}
+		if err == mongo.ErrNoDocuments {
+			return utils.ErrorResponse(c, 401, "Bad request")
+		}
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if previous.AvatarPublicID != "" {
+		if err := jobs.EnqueueMediaDeletions(c.Context(), []string{previous.AvatarPublicID}, "image", ""); err != nil {
+			log.Printf("could not queue old avatar %s: %v", previous.AvatarPublicID, err)
+		}
+		jobs.TriggerMediaCleanup()
+	}
+
+	user := previous
+	user.AvatarURL = uploadResult.SecureURL
+	user.AvatarPublicID = uploadResult.PublicID
+	return utils.SuccessResponse(c, 200, "Avatar updated", models.UserToPublicProfileResponse(&user))
+}
+
+// DeleteAvatar godoc
+// @Summary Remove Avatar
+// @Description Remove the avatar from the public profile
+// @Tags Profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse "Avatar removed"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/profile/avatar [delete]
+func DeleteAvatar(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	previous := models.User{}
+	err = database.GetCollection("users").FindOneAndUpdate(
+		c.Context(),
+		bson.M{"_id": userId},
+		bson.M{"$unset": bson.M{"avatarUrl": "", "avatarPublicId": ""}},
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if previous.AvatarPublicID != "" {
+		if err := jobs.EnqueueMediaDeletions(c.Context(), []string{previous.AvatarPublicID}, "image", ""); err != nil {
+			log.Printf("could not queue old avatar %s: %v", previous.AvatarPublicID, err)
+		}
+		jobs.TriggerMediaCleanup()
+	}
+
+	return utils.SuccessResponse(c, 200, "Avatar removed", nil)
+}
@@ -0,0 +1,419 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxPromptTitleLen = 120
+	maxActivePrompts  = 20
+)
+
+var (
+	errPromptNotFound = errors.New("prompt not found")
+	errPromptArchived = errors.New("prompt archived")
+	errTooManyPrompts = fmt.Errorf("You can have at most %d active prompts", maxActivePrompts)
+)
+
+// defaultPrompt returns userID's default prompt, creating it the first
+// time it is needed.
+func defaultPrompt(ctx context.Context, userID primitive.ObjectID) (*models.Prompt, error) {
+	return utils.EnsureDefaultPrompt(ctx, database.GetCollection("prompts"), userID)
+}
+
+// activatePrompt runs activate, which stores an active prompt at the
+// position it is given, if userID has room for another active prompt. The
+// position is after their last active prompt. Touching the default prompt
+// first makes concurrent calls for the same user conflict, so they take
+// turns instead of all counting the same prompts.
+func activatePrompt(ctx context.Context, userID primitive.ObjectID, activate func(ctx context.Context, position int) error) error {
+	if _, err := defaultPrompt(ctx, userID); err != nil {
+		return err
+	}
+	collection := database.GetCollection("prompts")
+	return database.RunInTransaction(ctx, func(ctx context.Context) error {
+		_, err := collection.UpdateOne(
+			ctx,
+			bson.M{"userId": userID, "isDefault": true},
+			bson.M{"$currentDate": bson.M{"promptsChangedAt": true}},
+		)
+		if err != nil {
+			return err
+		}
+
+		activeFilter := bson.M{"userId": userID, "archivedAt": bson.M{"$exists": false}}
+		active, err := collection.CountDocuments(ctx, activeFilter)
+		if err != nil {
+			return err
+		}
+		if active >= maxActivePrompts {
+			return errTooManyPrompts
+		}
+		last := models.Prompt{}
+		err = collection.FindOne(
+			ctx,
+			activeFilter,
+			options.FindOne().SetSort(bson.D{{Key: "position", Value: -1}}),
+		).Decode(&last)
+		if err != nil {
+			return err
+		}
+		return activate(ctx, last.Position+1)
+	})
+}
+
+// messagePrompt resolves the prompt a message sent to ownerID is filed
+// under. An empty promptID means the default prompt.
+func messagePrompt(ctx context.Context, ownerID primitive.ObjectID, promptID string) (*models.Prompt, error) {
+	if promptID == "" {
+		return defaultPrompt(ctx, ownerID)
+	}
+	id, err := primitive.ObjectIDFromHex(promptID)
+	if err != nil {
+		return nil, errPromptNotFound
+	}
+	prompt := &models.Prompt{}
+	err = database.GetCollection("prompts").FindOne(ctx, bson.M{"_id": id, "userId": ownerID}).Decode(prompt)
+	if err == mongo.ErrNoDocuments {
+		return nil, errPromptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if prompt.ArchivedAt != nil {
+		return nil, errPromptArchived
+	}
+	return prompt, nil
+}
+
+// promptTitle trims a prompt title and checks its length.
+func promptTitle(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || utf8.RuneCountInString(value) > maxPromptTitleLen {
+		return "", fmt.Errorf("title is required and must be at most %d characters", maxPromptTitleLen)
+	}
+	return value, nil
+}
+
+// GetPrompts godoc
+// @Summary List Prompts
+// @Description List the authenticated user's prompts in their display order, starting with the default prompt. Pass archived=true for archived prompts instead, most recently archived first.
+// @Tags Prompts
+// @Produce json
+// @Security BearerAuth
+// @Param archived query bool false "List archived prompts"
+// @Success 200 {object} utils.APIResponse{data=[]models.Prompt} "Prompts"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/prompts [get]
+func GetPrompts(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	archived, err := queryBool(c, "archived")
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	// Make sure a brand new inbox already shows its default prompt
+	if _, err := defaultPrompt(c.Context(), userId); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	filter := bson.M{"userId": userId, "archivedAt": bson.M{"$exists": false}}
+	sort := bson.D{{Key: "position", Value: 1}, {Key: "createdAt", Value: 1}}
+	if archived != nil && *archived {
+		filter["archivedAt"] = bson.M{"$exists": true}
+		sort = bson.D{{Key: "archivedAt", Value: -1}}
+	}
+	cursor, err := database.GetCollection("prompts").Find(c.Context(), filter, options.Find().SetSort(sort))
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	prompts := make([]models.Prompt, 0)
+	if err := cursor.All(c.Context(), &prompts); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", prompts)
+}
+
+// CreatePrompt godoc
+// @Summary Create Prompt
+// @Description Add a prompt (max 120 characters) with its own share link. It goes to the end of the list. Up to 20 prompts can be active at once.
+// @Tags Prompts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param promptData body models.CreatePromptRequestDTO true "Prompt title"
+// @Success 201 {object} utils.APIResponse{data=models.Prompt} "Prompt created"
+// @Failure 400 {object} utils.APIResponse "Bad request or too many prompts"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/prompts [post]
+func CreatePrompt(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.CreatePromptRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	title, err := promptTitle(requestData.Title)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	slug, err := utils.NewPromptSlug()
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	prompt := models.Prompt{
+		ID:        primitive.NewObjectID(),
+		UserID:    userId,
+		Title:     title,
+		Slug:      slug,
+		CreatedAt: time.Now(),
+	}
+	err = activatePrompt(c.Context(), userId, func(ctx context.Context, position int) error {
+		prompt.Position = position
+		_, err := database.GetCollection("prompts").InsertOne(ctx, prompt)
+		return err
+	})
+	if err == errTooManyPrompts {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Prompt created", prompt)
+}
+
+// UpdatePrompt godoc
+// @Summary Update Prompt
+// @Description Rename a prompt, or archive it so its link stops taking messages. Archived prompts keep their messages and can be brought back, at the end of the list. The default prompt cannot be archived.
+// @Tags Prompts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Prompt ID"
+// @Param promptData body models.UpdatePromptRequestDTO true "Fields to change"
+// @Success 200 {object} utils.APIResponse{data=models.Prompt} "Prompt updated"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 404 {object} utils.APIResponse "Prompt not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/prompts/{id} [patch]
+func UpdatePrompt(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	promptId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid prompt id")
+	}
+
+	requestData := models.UpdatePromptRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.Title == nil && requestData.Archived == nil {
+		return utils.ErrorResponse(c, 400, "Nothing to update")
+	}
+
+	collection := database.GetCollection("prompts")
+	filter := bson.M{"_id": promptId, "userId": userId}
+	prompt := models.Prompt{}
+	err = collection.FindOne(c.Context(), filter).Decode(&prompt)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This prompt does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	if requestData.Title != nil {
+		title, err := promptTitle(*requestData.Title)
+		if err != nil {
+			return utils.ErrorResponse(c, 400, err.Error())
+		}
+		set["title"] = title
+	}
+	if requestData.Archived != nil && *requestData.Archived != (prompt.ArchivedAt != nil) {
+		if *requestData.Archived {
+			if prompt.IsDefault {
+				return utils.ErrorResponse(c, 400, "The default prompt cannot be archived")
+			}
+			set["archivedAt"] = time.Now()
+		} else {
+			unset["archivedAt"] = ""
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return utils.SuccessResponse(c, 200, "Prompt updated", prompt)
+	}
+
+	apply := func(ctx context.Context) error {
+		update := bson.M{}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		return collection.FindOneAndUpdate(
+			ctx,
+			filter,
+			update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&prompt)
+	}
+	if len(unset) > 0 {
+		// A prompt brought back goes at the end of the list
+		err = activatePrompt(c.Context(), userId, func(ctx context.Context, position int) error {
+			set["position"] = position
+			return apply(ctx)
+		})
+	} else {
+		err = apply(c.Context())
+	}
+	if err == errTooManyPrompts {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This prompt does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Prompt updated", prompt)
+}
+
+// ReorderPrompts godoc
+// @Summary Reorder Prompts
+// @Description Set the display order of the authenticated user's active prompts. promptIds must list every active prompt exactly once.
+// @Tags Prompts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param orderData body models.ReorderPromptsRequestDTO true "Active prompt IDs in the new order"
+// @Success 200 {object} utils.APIResponse "Prompts reordered"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/prompts/order [put]
+func ReorderPrompts(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.ReorderPromptsRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+
+	collection := database.GetCollection("prompts")
+	cursor, err := collection.Find(
+		c.Context(),
+		bson.M{"userId": userId, "archivedAt": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	active := make([]models.Prompt, 0)
+	if err := cursor.All(c.Context(), &active); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	pending := make(map[primitive.ObjectID]bool, len(active))
+	for _, prompt := range active {
+		pending[prompt.ID] = true
+	}
+
+	const mismatch = "promptIds must list every active prompt exactly once"
+	if len(requestData.PromptIDs) != len(active) {
+		return utils.ErrorResponse(c, 400, mismatch)
+	}
+	writes := make([]mongo.WriteModel, 0, len(active))
+	for position, raw := range requestData.PromptIDs {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil || !pending[id] {
+			return utils.ErrorResponse(c, 400, mismatch)
+		}
+		delete(pending, id)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "userId": userId}).
+			SetUpdate(bson.M{"$set": bson.M{"position": position}}))
+	}
+	if len(writes) > 0 {
+		if _, err := collection.BulkWrite(c.Context(), writes); err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+	}
+
+	return utils.SuccessResponse(c, 200, "Prompts reordered", nil)
+}
+
+// GetPublicPrompt godoc
+// @Summary Get Prompt By Link
+// @Description The prompt behind a per-prompt share link and the profile of its owner. Send messages to it with ownerUsername and promptId.
+// @Tags Profile
+// @Produce json
+// @Param slug path string true "Prompt slug from a share link"
+// @Success 200 {object} utils.APIResponse{data=models.PublicPromptResponse} "Prompt"
+// @Failure 404 {object} utils.APIResponse "Prompt does not exist"
+// @Failure 410 {object} utils.APIResponse "Prompt is archived"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /p/{slug} [get]
+func GetPublicPrompt(c *fiber.Ctx) error {
+	prompt := models.Prompt{}
+	err := database.GetCollection("prompts").FindOne(c.Context(), bson.M{"slug": c.Params("slug")}).Decode(&prompt)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This prompt does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if prompt.ArchivedAt != nil {
+		return utils.ErrorResponse(c, 410, "This prompt is no longer taking messages")
+	}
+
+	user := models.User{}
+	err = database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": prompt.UserID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "This prompt does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", models.PromptToPublicPromptResponse(&prompt, &user))
+}
@@ -0,0 +1,76 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createPrompt adds a prompt and returns the status with the stored prompt.
+func (s *testServer) createPrompt(token, title string) (int, models.Prompt) {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/account/prompts", token, models.CreatePromptRequestDTO{Title: title})
+	prompt := models.Prompt{}
+	if status == 201 {
+		require.NoError(s.t, json.Unmarshal(res.Data, &prompt))
+	}
+	return status, prompt
+}
+
+func TestPromptPositions(t *testing.T) {
+	s := newTestServer(t)
+	s.register("vera", "", "correct horse")
+	token := s.login("vera", "correct horse")
+
+	_, first := s.createPrompt(token, "first")
+	_, second := s.createPrompt(token, "second")
+	assert.Equal(t, 1, first.Position)
+	assert.Equal(t, 2, second.Position)
+
+	archived := true
+	status, res := s.do(http.MethodPatch, "/account/prompts/"+second.ID.Hex(), token, models.UpdatePromptRequestDTO{Archived: &archived})
+	require.Equal(t, 200, status, res.Message)
+
+	// Archived prompts leave no gap behind the last active one
+	_, third := s.createPrompt(token, "third")
+	assert.Equal(t, 2, third.Position)
+
+	archived = false
+	status, res = s.do(http.MethodPatch, "/account/prompts/"+second.ID.Hex(), token, models.UpdatePromptRequestDTO{Archived: &archived})
+	require.Equal(t, 200, status, res.Message)
+	restored := models.Prompt{}
+	require.NoError(t, json.Unmarshal(res.Data, &restored))
+	assert.Equal(t, 3, restored.Position, "a restored prompt goes at the end")
+}
+
+func TestPromptLimit(t *testing.T) {
+	s := newTestServer(t)
+	s.register("wade", "", "correct horse")
+	token := s.login("wade", "correct horse")
+
+	// The default prompt is one of the 20
+	var last models.Prompt
+	for i := 1; i < 20; i++ {
+		status, prompt := s.createPrompt(token, fmt.Sprintf("prompt %d", i))
+		require.Equal(t, 201, status)
+		last = prompt
+	}
+	status, _ := s.createPrompt(token, "one too many")
+	assert.Equal(t, 400, status)
+
+	archived := true
+	status, res := s.do(http.MethodPatch, "/account/prompts/"+last.ID.Hex(), token, models.UpdatePromptRequestDTO{Archived: &archived})
+	require.Equal(t, 200, status, res.Message)
+	status, _ = s.createPrompt(token, "fits again")
+	require.Equal(t, 201, status)
+
+	// Now the archived one can't come back
+	archived = false
+	status, _ = s.do(http.MethodPatch, "/account/prompts/"+last.ID.Hex(), token, models.UpdatePromptRequestDTO{Archived: &archived})
+	assert.Equal(t, 400, status)
+}
@@ -0,0 +1,156 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/database/databasetest"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/routers"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// testServer is the full API on a throwaway database. Mail is captured by
+// a MemoryMailer instead of being sent.
+type testServer struct {
+	t      *testing.T
+	app    *fiber.App
+	mailer *utils.MemoryMailer
+}
+
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	databasetest.Open(t)
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	t.Setenv("JWT_SIGNING_KEYS", "test="+keyPath)
+	require.NoError(t, utils.InitJWTKeys())
+
+	// The cheapest parameters InitArgon2 accepts keep the tests fast
+	t.Setenv("ARGON2_TIME_COST", "1")
+	t.Setenv("ARGON2_MEMORY_KIB", "8192")
+	t.Setenv("ARGON2_THREADS", "1")
+	require.NoError(t, utils.InitArgon2())
+
+	mailer := &utils.MemoryMailer{}
+	config.Mailer = mailer
+	config.LoginAttempts = utils.NewMemoryAttemptStore()
+
+	// Requests come from the address in X-Forwarded-For, so tests can play
+	// clients on different networks
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	routers.UserRouter(app)
+	routers.MessageRouter(app)
+	routers.WellKnownRouter(app)
+	routers.AdminRouter(app)
+	routers.ProfileRouter(app)
+
+	return &testServer{t: t, app: app, mailer: mailer}
+}
+
+// do sends a JSON request, authenticated when token is set, and returns
+// the status code with the decoded response envelope.
+func (s *testServer) do(method, path, token string, body interface{}) (int, apiResponse) {
+	s.t.Helper()
+	return s.doFrom("192.0.2.1", method, path, token, body)
+}
+
+// doFrom is do for a client at ip.
+func (s *testServer) doFrom(ip, method, path, token string, body interface{}) (int, apiResponse) {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		require.NoError(s.t, err)
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(fiber.HeaderXForwardedFor, ip)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.app.Test(req, -1)
+	require.NoError(s.t, err)
+	defer resp.Body.Close()
+
+	res := apiResponse{}
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(s.t, err)
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &res)
+	}
+	return resp.StatusCode, res
+}
+
+// register creates an account and returns it as stored.
+func (s *testServer) register(username, email, password string) models.User {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/account/register", "", models.UserRequestDTO{
+		Username: username,
+		Email:    email,
+		Password: password,
+	})
+	require.Equal(s.t, 201, status, res.Message)
+
+	user := models.User{}
+	require.NoError(s.t, database.GetCollection("users").FindOne(context.Background(), bson.M{"username": username}).Decode(&user))
+	return user
+}
+
+// login signs in with a password and returns the access token.
+func (s *testServer) login(username, password string) string {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/account/login", "", models.UserRequestDTO{Username: username, Password: password})
+	require.Equal(s.t, 201, status, res.Message)
+
+	tokens := models.TokenPairResponse{}
+	require.NoError(s.t, json.Unmarshal(res.Data, &tokens))
+	return tokens.Token
+}
+
+var mailTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_.-]+)`)
+
+// mailToken returns the token in the link of the last mail sent to to.
+func (s *testServer) mailToken(to, subject string) string {
+	s.t.Helper()
+	sent := s.mailer.Sent()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].To == to && sent[i].Subject == subject {
+			match := mailTokenPattern.FindStringSubmatch(sent[i].Body)
+			require.NotNil(s.t, match, "no token in %q", sent[i].Body)
+			return match[1]
+		}
+	}
+	s.t.Fatalf("no %q mail sent to %s", subject, to)
+	return ""
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// createSession records a new signed-in device for user.
+func createSession(c *fiber.Ctx, user *models.User) (*models.Session, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		IP:         c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+	if _, err := database.GetCollection("sessions").InsertOne(c.Context(), session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// revokeSession ends one session along with its refresh-token family.
+func revokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	_, err := database.GetCollection("sessions").UpdateOne(
+		ctx,
+		bson.M{"_id": sessionID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	return revokeTokenFamily(ctx, sessionID)
+}
+
+// revokeAllSessions ends every session of userID. Bumping the token version
+// also invalidates access tokens that are still within their lifetime.
+// Personal access tokens are revoked too: whatever prompted this may have
+// been someone else creating them.
+func revokeAllSessions(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := revokeOtherSessions(ctx, userID, primitive.NilObjectID)
+	return err
+}
+
+// revokeOtherSessions is revokeAllSessions except that keepSessionID and its
+// refresh tokens survive. Its access token is still invalidated by the
+// version bump, so the caller has to hand that session a fresh one built
+// from the returned user.
+func revokeOtherSessions(ctx context.Context, userID, keepSessionID primitive.ObjectID) (*models.User, error) {
+	now := time.Now()
+	user := &models.User{}
+	if err := database.GetCollection("users").FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"tokenVersion": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(user); err != nil {
+		return nil, err
+	}
+	if _, err := database.GetCollection("sessions").UpdateMany(
+		ctx,
+		bson.M{"userId": userID, "_id": bson.M{"$ne": keepSessionID}, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	); err != nil {
+		return nil, err
+	}
+	if _, err := database.GetCollection("refresh_tokens").UpdateMany(
+		ctx,
+		bson.M{"userId": userID, "familyId": bson.M{"$ne": keepSessionID}, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	); err != nil {
+		return nil, err
+	}
+	if _, err := database.GetCollection("personal_access_tokens").UpdateMany(
+		ctx,
+		bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": now}},
+	); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current session and its refresh token
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse "Logged out"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/logout [post]
+func Logout(c *fiber.Ctx) error {
+	sessionId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("sessionId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	if err := revokeSession(c.Context(), sessionId); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Logged out", nil)
+}
+
+// LogoutAll godoc
+// @Summary Logout Everywhere
+// @Description Revoke every session and personal access token of the authenticated user, including the current session
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse "Logged out everywhere"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/logout-all [post]
+func LogoutAll(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	if err := revokeAllSessions(c.Context(), userId); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "Logged out everywhere", nil)
+}
+
+// GetSessions godoc
+// @Summary List Sessions
+// @Description List the active sessions (devices) of the authenticated user
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.SessionResponse} "Active sessions"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/sessions [get]
+func GetSessions(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	currentSessionId := fmt.Sprintf("%v", c.Locals("sessionId"))
+
+	cursor, err := database.GetCollection("sessions").Find(
+		c.Context(),
+		bson.M{
+			"userId":    userId,
+			"revokedAt": bson.M{"$exists": false},
+			"expiresAt": bson.M{"$gt": time.Now()},
+		},
+		options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}}),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	sessions := make([]models.SessionResponse, 0)
+	for cursor.Next(c.Context()) {
+		session := models.Session{}
+		if err := cursor.Decode(&session); err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		sessions = append(sessions, models.SessionToSessionResponse(&session, currentSessionId))
+	}
+
+	return utils.SuccessResponse(c, 200, "", sessions)
+}
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	senderTokenHeader   = "X-Sender-Token"
+	maxThreadReplyLen   = 2000
+	maxRepliesPerThread = 200
+)
+
+var errThreadFull = errors.New("thread is full")
+
+// issueSenderToken gives message a token its sender can later use to follow
+// the thread. Only the hash is stored, so it is returned once.
+func issueSenderToken(message *models.Message) (string, error) {
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	message.SenderTokenHash = utils.HashToken(token)
+	return token, nil
+}
+
+// threadReplies returns the replies under messageID, oldest first.
+func threadReplies(ctx context.Context, messageID primitive.ObjectID) ([]models.MessageReply, error) {
+	cursor, err := database.GetCollection("message_replies").Find(
+		ctx,
+		bson.M{"messageId": messageID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	replies := make([]models.MessageReply, 0)
+	if err := cursor.All(ctx, &replies); err != nil {
+		return nil, err
+	}
+	return replies, nil
+}
+
+// addThreadReply stores a reply under message and updates its reply count.
+// A follow-up from the sender also marks the message unread so the owner
+// sees it.
+func addThreadReply(ctx context.Context, message *models.Message, author, body string) (*models.MessageReply, error) {
+	reply := &models.MessageReply{
+		ID:        primitive.NewObjectID(),
+		MessageID: message.ID,
+		OwnerID:   message.OwnerID,
+		Author:    author,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	set := bson.M{"lastReplyAt": reply.CreatedAt}
+	if author == models.ReplyAuthorSender {
+		set["isOpened"] = false
+	}
+
+	err := database.RunInTransaction(ctx, func(ctx context.Context) error {
+		result, err := database.GetCollection("messages").UpdateOne(
+			ctx,
+			bson.M{
+				"_id": message.ID,
+				// Messages from before threads have no count yet
+				"$or": []bson.M{
+					{"replyCount": bson.M{"$exists": false}},
+					{"replyCount": bson.M{"$lt": maxRepliesPerThread}},
+				},
+			},
+			bson.M{"$inc": bson.M{"replyCount": 1}, "$set": set},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return errThreadFull
+		}
+		_, err = database.GetCollection("message_replies").InsertOne(ctx, reply)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// threadReplyBody reads and checks the text of a reply.
+func threadReplyBody(c *fiber.Ctx) (string, error) {
+	requestData := models.ThreadReplyRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return "", errors.New("Invalid Json")
+	}
+	body := strings.TrimSpace(requestData.Text)
+	if body == "" || utf8.RuneCountInString(body) > maxThreadReplyLen {
+		return "", errors.New("text is required and must be at most 2000 characters")
+	}
+	return body, nil
+}
+
+// senderMessage finds the message the X-Sender-Token header belongs to.
+// Once the owner trashes it, the thread is gone for the sender too.
+func senderMessage(c *fiber.Ctx) (*models.Message, error) {
+	token := c.Get(senderTokenHeader)
+	if token == "" {
+		return nil, mongo.ErrNoDocuments
+	}
+	message := &models.Message{}
+	err := database.GetCollection("messages").FindOne(
+		c.Context(),
+		bson.M{"senderTokenHash": utils.HashToken(token), "deletedAt": bson.M{"$exists": false}},
+	).Decode(message)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// GetThread godoc
+// @Summary Get Message Thread
+// @Description Get one of the authenticated user's messages with the private replies exchanged with its anonymous sender
+// @Tags Threads
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} utils.APIResponse{data=models.ThreadResponse} "Message and replies"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Message not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/thread [get]
+func GetThread(c *fiber.Ctx) error {
+	_, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+
+	replies, err := threadReplies(c.Context(), message.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", models.ThreadResponse{Message: *message, Replies: replies})
+}
+
+// ReplyToThread godoc
+// @Summary Reply Privately
+// @Description Send a private reply to the anonymous sender of one of the authenticated user's messages. Only the holder of the sender token can read it.
+// @Tags Threads
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Param replyData body models.ThreadReplyRequestDTO true "Reply text"
+// @Success 201 {object} utils.APIResponse{data=models.MessageReply} "Reply sent"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 404 {object} utils.APIResponse "Message not found"
+// @Failure 409 {object} utils.APIResponse "Thread has reached its reply limit, or the message has no sender to reply to"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/{id}/thread/replies [post]
+func ReplyToThread(c *fiber.Ctx) error {
+	_, message, err := answerTarget(c)
+	if message == nil {
+		return err
+	}
+	// Without a sender token nobody could ever read the reply
+	if message.SenderTokenHash == "" {
+		return utils.ErrorResponse(c, 409, "This message's sender can't receive replies")
+	}
+	body, err := threadReplyBody(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	reply, err := addThreadReply(c.Context(), message, models.ReplyAuthorOwner, body)
+	if err == errThreadFull {
+		return utils.ErrorResponse(c, 409, "This thread has reached its reply limit")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Reply sent", reply)
+}
+
+// GetSenderThread godoc
+// @Summary Get Thread As Sender
+// @Description Anonymous senders read the replies to their message by presenting the senderToken they got when sending it, in the X-Sender-Token header
+// @Tags Threads
+// @Produce json
+// @Param X-Sender-Token header string true "Sender token"
+// @Success 200 {object} utils.APIResponse{data=models.SenderThreadResponse} "Message and replies"
+// @Failure 404 {object} utils.APIResponse "Unknown sender token"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/sender/thread [get]
+func GetSenderThread(c *fiber.Ctx) error {
+	message, err := senderMessage(c)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "Unknown sender token")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	replies, err := threadReplies(c.Context(), message.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", models.MessageToSenderThreadResponse(message, replies))
+}
+
+// ReplyAsSender godoc
+// @Summary Follow Up As Sender
+// @Description Anonymous senders add a follow-up to their message's thread by presenting their sender token in the X-Sender-Token header. The message shows as unread to its owner again.
+// @Tags Threads
+// @Accept json
+// @Produce json
+// @Param X-Sender-Token header string true "Sender token"
+// @Param replyData body models.ThreadReplyRequestDTO true "Follow-up text"
+// @Success 201 {object} utils.APIResponse{data=models.MessageReply} "Follow-up sent"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 404 {object} utils.APIResponse "Unknown sender token"
+// @Failure 409 {object} utils.APIResponse "Thread has reached its reply limit"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/sender/thread/replies [post]
+func ReplyAsSender(c *fiber.Ctx) error {
+	message, err := senderMessage(c)
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "Unknown sender token")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	body, err := threadReplyBody(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	reply, err := addThreadReply(c.Context(), message, models.ReplyAuthorSender, body)
+	if err == errThreadFull {
+		return utils.ErrorResponse(c, 409, "This thread has reached its reply limit")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Follow-up sent", reply)
+}
@@ -0,0 +1,68 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReplyToThread(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.register("tara", "", "correct horse")
+	token := s.login("tara", "correct horse")
+	ctx := context.Background()
+	messages := database.GetCollection("messages")
+	reply := models.ThreadReplyRequestDTO{Text: "thank you!"}
+
+	t.Run("message from before threads", func(t *testing.T) {
+		// Stored without a replyCount field at all
+		id := primitive.NewObjectID()
+		_, err := messages.InsertOne(ctx, bson.M{
+			"_id":             id,
+			"schemaVersion":   models.MessageSchemaVersion,
+			"type":            models.MessageTypeText,
+			"ownerId":         owner.ID,
+			"text":            bson.M{"body": "hello"},
+			"createdAt":       time.Now(),
+			"senderTokenHash": "sender-token-hash",
+		})
+		require.NoError(t, err)
+
+		status, res := s.do(http.MethodPost, "/message/"+id.Hex()+"/thread/replies", token, reply)
+		require.Equal(t, 201, status, res.Message)
+		stored := models.Message{}
+		require.NoError(t, messages.FindOne(ctx, bson.M{"_id": id}).Decode(&stored))
+		assert.Equal(t, 1, stored.ReplyCount)
+	})
+
+	t.Run("message without a sender token", func(t *testing.T) {
+		message := models.NewTextMessage(owner.ID, primitive.NilObjectID, "hello")
+		_, err := messages.InsertOne(ctx, message)
+		require.NoError(t, err)
+
+		status, _ := s.do(http.MethodPost, "/message/"+message.ID.Hex()+"/thread/replies", token, reply)
+		assert.Equal(t, 409, status)
+		replies, err := database.GetCollection("message_replies").CountDocuments(ctx, bson.M{"messageId": message.ID})
+		require.NoError(t, err)
+		assert.Zero(t, replies)
+	})
+
+	t.Run("full thread", func(t *testing.T) {
+		message := models.NewTextMessage(owner.ID, primitive.NilObjectID, "hello")
+		message.SenderTokenHash = "another-sender-token-hash"
+		message.ReplyCount = 200
+		_, err := messages.InsertOne(ctx, message)
+		require.NoError(t, err)
+
+		status, _ := s.do(http.MethodPost, "/message/"+message.ID.Hex()+"/thread/replies", token, reply)
+		assert.Equal(t, 409, status)
+	})
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// accessTokenTTL is in minutes, matching utils.GenerateJWT.
+	accessTokenTTL  = 10
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// issueTokenPair mints an access token and a new refresh token for session.
+// The session ID is used as the refresh-token family.
+func issueTokenPair(ctx context.Context, user *models.User, sessionID primitive.ObjectID) (models.TokenPairResponse, error) {
+	accessToken, err := utils.GenerateJWT(utils.AccessClaims{
+		Email:        user.Email,
+		UserID:       user.ID.Hex(),
+		SessionID:    sessionID.Hex(),
+		TokenVersion: user.TokenVersion,
+		Role:         user.EffectiveRole(),
+	}, accessTokenTTL)
+	if err != nil {
+		return models.TokenPairResponse{}, err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return models.TokenPairResponse{}, err
+	}
+
+	now := time.Now()
+	record := models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: utils.HashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(refreshTokenTTL),
+	}
+	if _, err := database.GetCollection("refresh_tokens").InsertOne(ctx, record); err != nil {
+		return models.TokenPairResponse{}, err
+	}
+
+	return models.TokenPairResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    accessTokenTTL * 60,
+	}, nil
+}
+
+// revokeTokenFamily revokes every refresh token descended from the same login.
+func revokeTokenFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	_, err := database.GetCollection("refresh_tokens").UpdateMany(
+		ctx,
+		bson.M{"familyId": familyID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	return err
+}
+
+// RefreshToken godoc
+// @Summary Refresh Access Token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token. Presenting an already-used refresh token revokes every token from the same login.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param refreshData body models.RefreshTokenRequestDTO true "Refresh token"
+// @Success 201 {object} utils.APIResponse{data=models.TokenPairResponse} "Token refreshed"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Invalid or expired refresh token"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/refresh [post]
+func RefreshToken(c *fiber.Ctx) error {
+	requestData := models.RefreshTokenRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	if requestData.RefreshToken == "" {
+		return utils.ErrorResponse(c, 400, "refreshToken is required")
+	}
+
+	tokenCollection := database.GetCollection("refresh_tokens")
+	tokenHash := utils.HashToken(requestData.RefreshToken)
+	now := time.Now()
+
+	// Claim the token atomically so two concurrent refreshes can't both win.
+	current := models.RefreshToken{}
+	err := tokenCollection.FindOneAndUpdate(
+		c.Context(),
+		bson.M{
+			"tokenHash": tokenHash,
+			"rotatedAt": bson.M{"$exists": false},
+			"revokedAt": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"rotatedAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		reused := models.RefreshToken{}
+		if err := tokenCollection.FindOne(c.Context(), bson.M{"tokenHash": tokenHash}).Decode(&reused); err == nil {
+			// A rotated or revoked token came back: assume it was stolen.
+			if err := revokeSession(c.Context(), reused.FamilyID); err != nil {
+				return utils.ErrorResponse(c, 500, "Internal server error")
+			}
+		}
+		return utils.ErrorResponse(c, 401, "Invalid or expired refresh token")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if now.After(current.ExpiresAt) {
+		return utils.ErrorResponse(c, 401, "Invalid or expired refresh token")
+	}
+
+	// Extend the session this token belongs to, unless it was logged out.
+	sessionUpdate := database.GetCollection("sessions").FindOneAndUpdate(
+		c.Context(),
+		bson.M{"_id": current.FamilyID, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"lastSeenAt": now,
+			"ip":         c.IP(),
+			"expiresAt":  now.Add(refreshTokenTTL),
+		}},
+	)
+	if err := sessionUpdate.Err(); err != nil {
+		return utils.ErrorResponse(c, 401, "Invalid or expired refresh token")
+	}
+
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": current.UserID}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Invalid or expired refresh token")
+	}
+
+	tokens, err := issueTokenPair(c.Context(), &user, current.FamilyID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Token refreshed", tokens)
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetTrash godoc
+// @Summary Get Trash
+// @Description Page through the authenticated user's trashed messages, most recently deleted first by default. Each can be restored until 30 days after its deletedAt.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size, 1-100 (default 20)"
+// @Param cursor query string false "nextCursor from the previous page"
+// @Param order query string false "desc (default) or asc by deletion time"
+// @Param unread query bool false "Only unread (true) or read (false) messages"
+// @Param starred query bool false "Only starred (true) or unstarred (false) messages"
+// @Param type query string false "text or audio"
+// @Param promptId query string false "Only messages sent to this prompt"
+// @Param from query string false "Created at or after, RFC 3339"
+// @Param to query string false "Created before, RFC 3339"
+// @Success 200 {object} utils.APIResponse{data=models.MessagePageResponse} "A page of trashed messages"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/trash [get]
+func GetTrash(c *fiber.Ctx) error {
+	access, err := messageAccessFor(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Bad Request")
+	}
+
+	filters, err := messageFilters(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	limit, sort, after, err := pageQuery(c, "deletedAt")
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	if after != nil {
+		filters = append(filters, after)
+	}
+
+	// One extra document tells us whether there is a next page
+	messages, err := access.inTrash().list(c.Context(), filters, options.Find().SetSort(sort).SetLimit(limit+1))
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	page := models.MessagePageResponse{Messages: messages}
+	if int64(len(messages)) > limit {
+		page.Messages = messages[:limit]
+		last := page.Messages[limit-1]
+		page.NextCursor = utils.EncodeCursor(*last.DeletedAt, last.ID)
+	}
+
+	return utils.SuccessResponse(c, 200, "", page)
+}
+
+// RestoreMessage godoc
+// @Summary Restore Message
+// @Description Move a message from the authenticated user's trash back to their inbox
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 201 {object} utils.APIResponse{data=models.Message} "Message restored"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Not in the trash, or too old to restore"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/trash/{id}/restore [post]
+func RestoreMessage(c *fiber.Ctx) error {
+	access, err := messageAccessFor(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "")
+	}
+	messageObjectId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid message id")
+	}
+
+	message, err := access.restore(c.Context(), messageObjectId)
+	if err == errMessageNotFound {
+		return utils.ErrorResponse(c, 404, "This message is not in the trash")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Message restored", message)
+}
+
+// DeleteTrashedMessage godoc
+// @Summary Delete Message Forever
+// @Description Permanently delete a message from the authenticated user's trash, along with its stored audio, without waiting for the purge
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 201 {object} utils.APIResponse "Message deleted"
+// @Failure 400 {object} utils.APIResponse "Invalid message ID"
+// @Failure 404 {object} utils.APIResponse "Message not in the trash"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/trash/{id} [delete]
+func DeleteTrashedMessage(c *fiber.Ctx) error {
+	access, err := messageAccessFor(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "")
+	}
+	messageObjectId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid message id")
+	}
+
+	err = access.inTrash().deleteOne(c.Context(), messageObjectId)
+	if err == errMessageNotFound {
+		return utils.ErrorResponse(c, 404, "This message is not in the trash")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Message deleted", nil)
+}
+
+// EmptyTrash godoc
+// @Summary Empty Trash
+// @Description Permanently delete every message in the authenticated user's trash, along with their stored audio
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "Trash emptied"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /message/trash [delete]
+func EmptyTrash(c *fiber.Ctx) error {
+	access, err := messageAccessFor(c)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Bad Request")
+	}
+
+	deleted, err := access.inTrash().delete(c.Context())
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Trash emptied", fiber.Map{"deletedCount": len(deleted)})
+}
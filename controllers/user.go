@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/Investorharry19/voxa-golang-server/database"
 	"github.com/Investorharry19/voxa-golang-server/models"
@@ -12,21 +15,37 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// isDuplicateKey reports whether err is a unique index violation on index.
+func isDuplicateKey(err error, index string) bool {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return false
+	}
+	for _, writeErr := range we.WriteErrors {
+		if writeErr.Code == 11000 && strings.Contains(writeErr.Message, "index: "+index+" ") {
+			return true
+		}
+	}
+	return false
+}
+
 func GetUsers(c *fiber.Ctx) error {
 	return c.Status(200).JSON(fiber.Map{"status": "Okay"})
 }
 
 // RegisterUser godoc
 // @Summary Register a new user
-// @Description Register a new user with username and password
+// @Description Register a new user with username and password, and optionally an email address. Usernames are 3-30 letters, digits or underscores, unique regardless of case.
 // @Tags Account
 // @Accept json
 // @Produce json
 // @Param registerData body models.UserRequestDTO true "User registration data"
 // @Success 201 {object} utils.APIResponse "User created successfully"
-// @Failure 400 {object} utils.APIResponse "Bad request or missing fields"
+// @Failure 400 {object} utils.APIResponse "Bad request, missing fields or username does not meet the policy"
 // @Failure 461 {object} utils.APIResponse "Username already exists"
+// @Failure 462 {object} utils.APIResponse "Email already exists"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Server busy"
 // @Router /account/register [post]
 func RegisterUser(c *fiber.Ctx) error {
 	println("Register")
@@ -38,16 +57,50 @@ func RegisterUser(c *fiber.Ctx) error {
 	if registerData.Password == "" || registerData.Username == "" {
 		return utils.ErrorResponse(c, 400, "Username and password are required")
 	}
+	username, err := utils.NormalizeUsername(registerData.Username)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+	email := ""
+	if registerData.Email != "" {
+		normalized, err := utils.NormalizeEmail(registerData.Email)
+		if err != nil {
+			return utils.ErrorResponse(c, 400, "Invalid email address")
+		}
+		email = normalized
+	}
+
+	// The unique index can't see aliases still held by renamed accounts
+	taken, err := usernameTaken(c.Context(), username, primitive.NilObjectID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server Error")
+	}
+	if taken {
+		return utils.ErrorResponse(c, 461, "Username already exists")
+	}
+	if email != "" {
+		taken, err := emailTaken(c.Context(), email, primitive.NilObjectID)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server Error")
+		}
+		if taken {
+			return utils.ErrorResponse(c, 462, "Email already exists")
+		}
+	}
 
 	hashed, err := utils.HashPasswordSecure(registerData.Password)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
 	if err != nil {
 		return utils.ErrorResponse(c, 500, "Internal server Error")
 	}
 
 	userCollection := database.GetCollection("users")
 	saveUser := models.User{
-		Username:  registerData.Username,
+		Username:  username,
 		Password:  hashed,
+		Email:     email,
 		PushToken: make([]string, 0),
 		ID:        primitive.NewObjectID(),
 	}
@@ -64,20 +117,29 @@ func RegisterUser(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, 500, err.Error())
 	}
 
+	if saveUser.Email != "" {
+		if err := sendEmailVerification(c.Context(), &saveUser); err != nil {
+			log.Printf("verification email for %s failed: %v", saveUser.ID.Hex(), err)
+		}
+	}
+
 	return utils.SuccessResponse(c, 201, "User Created", res)
 }
 
 // LoginUser godoc
 // @Summary Login User
-// @Description Authenticate a user and return a JWT token
+// @Description Authenticate a user by username or verified email address and return a short-lived JWT and a refresh token. Accounts with two-factor enabled get a 202 with an mfaToken to complete at /account/login/mfa.
 // @Tags Account
 // @Accept json
 // @Produce json
 // @Param loginData body models.UserRequestDTO true "User login data"
-// @Success 201 {object} map[string]interface{} "Logged In"
+// @Success 201 {object} utils.APIResponse{data=models.TokenPairResponse} "Logged In"
+// @Success 202 {object} utils.APIResponse{data=models.MFAChallengeResponse} "Two-factor code required"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 404 {object} map[string]string "Invalid Credentials"
+// @Failure 429 {object} map[string]string "Too many failed attempts; see the Retry-After header"
 // @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 503 {object} map[string]string "Server busy"
 // @Router /account/login [post]
 func LoginUser(c *fiber.Ctx) error {
 	loginData := models.UserRequestDTO{}
@@ -89,27 +151,90 @@ func LoginUser(c *fiber.Ctx) error {
 	}
 
 	userCollection := database.GetCollection("users")
-	userdata := models.User{}
-	if err := userCollection.FindOne(c.Context(), bson.M{"username": loginData.Username}).Decode(&userdata); err != nil {
+	user, err := findUserByLogin(c.Context(), loginData.Username)
+	if err != nil {
+		user = nil
+	}
+
+	// Refuse before hashing anything while the account or IP is backing off
+	keys := loginKeys(c, loginData.Username, user)
+	wait, err := loginRetryAfter(c.Context(), keys)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		return utils.ErrorResponse(c, 429, "Too many login attempts, please try again later")
+	}
+
+	if user == nil {
+		if wait, _ := recordLoginFailure(c.Context(), keys); wait > 0 {
+			setRetryAfter(c, wait)
+		}
 		return utils.ErrorResponse(c, 404, "Invalid Credentials")
 	}
+	userdata := *user
 
 	passwordMatch, err := utils.VerifyPasswordSecure(userdata.Password, loginData.Password)
-	fmt.Println(loginData.Password, userdata.Password, passwordMatch)
+	if errors.Is(err, utils.ErrHasherBusy) {
+		return utils.ErrorResponse(c, 503, "Server busy, please try again")
+	}
 	if err != nil {
 		println(err)
 		return utils.ErrorResponse(c, 500, "Internal server error")
 	}
-	println(76)
 	if !passwordMatch {
+		wait, locked := recordLoginFailure(c.Context(), keys)
+		recordSecurityEvent(c, userdata.ID, models.SecurityEventLoginFailed)
+		if locked {
+			recordSecurityEvent(c, userdata.ID, models.SecurityEventLoginLocked)
+		}
+		if wait > 0 {
+			setRetryAfter(c, wait)
+		}
 		return utils.ErrorResponse(c, 404, "Invalid Credentials")
 	}
 
-	token, _ := utils.GenerateJWT("", string(userdata.ID.Hex()), 10)
+	// Upgrade hashes made with older or weaker Argon2 parameters while we
+	// still hold the plaintext. Failure here must not block the login.
+	if utils.PasswordNeedsRehash(userdata.Password) {
+		if rehashed, err := utils.HashPasswordSecure(loginData.Password); err == nil {
+			_, err = userCollection.UpdateOne(
+				c.Context(),
+				bson.M{"_id": userdata.ID, "password_hash": userdata.Password},
+				bson.M{"$set": bson.M{"password_hash": rehashed}},
+			)
+			if err != nil {
+				log.Printf("password rehash failed for %s: %v", userdata.ID.Hex(), err)
+			}
+		}
+	}
+
+	// With two-factor on, the password only earns a challenge token
+	if userdata.MFAEnabled {
+		challenge, err := issueMFAChallenge(&userdata)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		return utils.SuccessResponse(c, 202, "Two-factor code required", challenge)
+	}
+
+	// The IP count is left alone so one known password can't clear it. With
+	// two-factor on, LoginMFA does this once the second factor passes, so a
+	// stolen password can't keep clearing failed codes either.
+	clearLoginFailures(c.Context(), keys)
+
+	session, err := createSession(c, &userdata)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	tokens, err := issueTokenPair(c.Context(), &userdata, session.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
 
-	return utils.SuccessResponse(c, 201, "Logged In", struct {
-		Token string `json:"token"`
-	}{Token: token})
+	return utils.SuccessResponse(c, 201, "Logged In", tokens)
 }
 
 // GetCurrentUser godoc
@@ -140,7 +265,17 @@ func GetCurrentUser(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, 400, "Bad request")
 	}
 
-	token, _ := utils.GenerateJWT("", userResponse.ID.Hex(), 60*24*7)
+	// Personal access tokens have no session to mint a JWT for
+	token := ""
+	if sessionId, ok := c.Locals("sessionId").(string); ok {
+		token, _ = utils.GenerateJWT(utils.AccessClaims{
+			Email:        userResponse.Email,
+			UserID:       userResponse.ID.Hex(),
+			SessionID:    sessionId,
+			TokenVersion: userResponse.TokenVersion,
+			Role:         userResponse.EffectiveRole(),
+		}, accessTokenTTL)
+	}
 	return utils.SuccessResponse(
 		c, 201, "User authenticated", models.UserToUserResponse(&userResponse, token))
 }
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// usernameAliasTTL is how long an old username keeps delivering to the
+	// account after a rename
+	usernameAliasTTL = 30 * 24 * time.Hour
+	// usernameChangeCooldown stops one account from parking many names as
+	// aliases
+	usernameChangeCooldown = 7 * 24 * time.Hour
+)
+
+// findUserByUsername resolves a share-link username to its account, case
+// insensitively and following unexpired aliases. isAlias reports whether an
+// alias was followed.
+func findUserByUsername(ctx context.Context, username string) (user *models.User, isAlias bool, err error) {
+	userCollection := database.GetCollection("users")
+	user = &models.User{}
+	err = userCollection.FindOne(
+		ctx,
+		bson.M{"username": username},
+		options.FindOne().SetCollation(database.UsernameCollation),
+	).Decode(user)
+	if err != mongo.ErrNoDocuments {
+		return user, false, err
+	}
+
+	alias := models.UsernameAlias{}
+	if err := database.GetCollection("username_aliases").FindOne(
+		ctx,
+		bson.M{"username": username, "expiresAt": bson.M{"$gt": time.Now()}},
+		options.FindOne().SetCollation(database.UsernameCollation),
+	).Decode(&alias); err != nil {
+		return nil, false, err
+	}
+	if err := userCollection.FindOne(ctx, bson.M{"_id": alias.UserID}).Decode(user); err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// usernameTaken reports whether username belongs to, or is a live alias of,
+// an account other than userID. Pass primitive.NilObjectID for new accounts.
+func usernameTaken(ctx context.Context, username string, userID primitive.ObjectID) (bool, error) {
+	collation := options.Count().SetCollation(database.UsernameCollation)
+
+	count, err := database.GetCollection("users").CountDocuments(
+		ctx, bson.M{"username": username, "_id": bson.M{"$ne": userID}}, collation)
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	count, err = database.GetCollection("username_aliases").CountDocuments(
+		ctx,
+		bson.M{"username": username, "userId": bson.M{"$ne": userID}, "expiresAt": bson.M{"$gt": time.Now()}},
+		collation,
+	)
+	return count > 0, err
+}
+
+// ChangeUsername godoc
+// @Summary Change Username
+// @Description Change the authenticated user's username. The previous name stays a redirect alias for 30 days so existing share links keep working. Allowed once a week.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param usernameData body models.UpdateUsernameRequestDTO true "New username"
+// @Success 200 {object} utils.APIResponse{data=models.UsernameChangeResponse} "Username changed"
+// @Failure 400 {object} utils.APIResponse "Username does not meet the policy"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 429 {object} utils.APIResponse "Changed too recently"
+// @Failure 461 {object} utils.APIResponse "Username already exists"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/username [put]
+func ChangeUsername(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.UpdateUsernameRequestDTO{}
+	if err := c.BodyParser(&requestData); err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid Json")
+	}
+	username, err := utils.NormalizeUsername(requestData.Username)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, err.Error())
+	}
+
+	userCollection := database.GetCollection("users")
+	user := models.User{}
+	if err := userCollection.FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	if username == user.Username {
+		return utils.ErrorResponse(c, 400, "That is already your username")
+	}
+
+	// A change of case only keeps the same links working, so it needs no
+	// alias and doesn't count against the cooldown
+	caseOnly := strings.EqualFold(username, user.Username)
+	now := time.Now()
+	if !caseOnly && user.UsernameChangedAt != nil {
+		if next := user.UsernameChangedAt.Add(usernameChangeCooldown); now.Before(next) {
+			setRetryAfter(c, next.Sub(now))
+			return utils.ErrorResponse(c, 429, "You can change your username again after "+next.Format(time.RFC1123))
+		}
+	}
+
+	taken, err := usernameTaken(c.Context(), username, userId)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if taken {
+		return utils.ErrorResponse(c, 461, "Username already exists")
+	}
+
+	update := bson.M{"username": username}
+	if !caseOnly {
+		update["usernameChangedAt"] = now
+	}
+	result, err := userCollection.UpdateOne(
+		c.Context(),
+		bson.M{"_id": userId, "username": user.Username},
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		if isDuplicateKey(err, "username_ci_unique") {
+			return utils.ErrorResponse(c, 461, "Username already exists")
+		}
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrorResponse(c, 409, "Username was changed by another request")
+	}
+
+	response := models.UsernameChangeResponse{
+		Username:         username,
+		PreviousUsername: user.Username,
+	}
+	aliasCollection := database.GetCollection("username_aliases")
+	if !caseOnly {
+		expiresAt := now.Add(usernameAliasTTL)
+		if _, err := aliasCollection.UpdateOne(
+			c.Context(),
+			bson.M{"username": user.Username},
+			bson.M{
+				"$set":         bson.M{"username": user.Username, "userId": userId, "expiresAt": expiresAt},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "createdAt": now},
+			},
+			options.Update().SetUpsert(true).SetCollation(database.UsernameCollation),
+		); err != nil {
+			log.Printf("could not keep alias %q for %s: %v", user.Username, userId.Hex(), err)
+		} else {
+			response.AliasExpiresAt = &expiresAt
+		}
+	}
+	// Taking back one of our own old names retires that alias
+	if _, err := aliasCollection.DeleteOne(
+		c.Context(),
+		bson.M{"username": username, "userId": userId},
+		options.Delete().SetCollation(database.UsernameCollation),
+	); err != nil {
+		log.Printf("could not drop alias %q for %s: %v", username, userId.Hex(), err)
+	}
+
+	return utils.SuccessResponse(c, 200, "Username changed", response)
+}
+
+// ResolveUsername godoc
+// @Summary Resolve Username
+// @Description Resolve a share-link username to the account's current username, following case differences and aliases left by recent renames
+// @Tags Account
+// @Produce json
+// @Param username path string true "Username from a share link"
+// @Success 200 {object} utils.APIResponse{data=models.ResolvedUsernameResponse} "Current username"
+// @Failure 404 {object} utils.APIResponse "User does not exist"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/username/{username} [get]
+func ResolveUsername(c *fiber.Ctx) error {
+	user, isAlias, err := findUserByUsername(c.Context(), c.Params("username"))
+	if err == mongo.ErrNoDocuments {
+		return utils.ErrorResponse(c, 404, "User does not exist")
+	}
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", models.ResolvedUsernameResponse{
+		Username: user.Username,
+		IsAlias:  isAlias,
+	})
+}
@@ -0,0 +1,393 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webauthnChallengeTTL = 5 * time.Minute
+	maxPasskeysPerUser   = 20
+	maxPasskeyNameLen    = 100
+)
+
+// loadWebAuthnUser pairs user with their registered passkeys.
+func loadWebAuthnUser(ctx context.Context, user *models.User) (*models.WebAuthnUser, error) {
+	cursor, err := database.GetCollection("webauthn_credentials").Find(ctx, bson.M{"userId": user.ID})
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]webauthn.Credential, 0)
+	for cursor.Next(ctx) {
+		stored := models.WebAuthnCredential{}
+		if err := cursor.Decode(&stored); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, stored.Credential)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return &models.WebAuthnUser{User: user, Credentials: credentials}, nil
+}
+
+// saveWebAuthnChallenge keeps session until the matching finish call.
+func saveWebAuthnChallenge(ctx context.Context, ceremony string, userID *primitive.ObjectID, name string, session *webauthn.SessionData) error {
+	now := time.Now()
+	_, err := database.GetCollection("webauthn_challenges").InsertOne(ctx, models.WebAuthnChallenge{
+		ID:        primitive.NewObjectID(),
+		Challenge: session.Challenge,
+		Ceremony:  ceremony,
+		UserID:    userID,
+		Name:      name,
+		Session:   *session,
+		CreatedAt: now,
+		ExpiresAt: now.Add(webauthnChallengeTTL),
+	})
+	return err
+}
+
+// consumeWebAuthnChallenge deletes and returns the unexpired challenge the
+// browser signed, so each one can only be finished once.
+func consumeWebAuthnChallenge(ctx context.Context, filter bson.M) (*models.WebAuthnChallenge, error) {
+	filter["expiresAt"] = bson.M{"$gt": time.Now()}
+	stored := models.WebAuthnChallenge{}
+	if err := database.GetCollection("webauthn_challenges").FindOneAndDelete(ctx, filter).Decode(&stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// BeginPasskeyRegistration godoc
+// @Summary Start Passkey Registration
+// @Description Create the options for navigator.credentials.create(). Pass the resulting credential, as JSON, to the finish endpoint within five minutes.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param passkeyData body models.BeginPasskeyRegistrationRequestDTO false "Optional name for the new passkey"
+// @Success 200 {object} utils.APIResponse{data=protocol.CredentialCreation} "Credential creation options"
+// @Failure 400 {object} utils.APIResponse "Bad request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/passkeys/register/begin [post]
+func BeginPasskeyRegistration(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	requestData := models.BeginPasskeyRegistrationRequestDTO{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&requestData); err != nil {
+			return utils.ErrorResponse(c, 400, "Invalid Json")
+		}
+	}
+	name := strings.TrimSpace(requestData.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > maxPasskeyNameLen {
+		return utils.ErrorResponse(c, 400, "name must be at most 100 characters")
+	}
+
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	waUser, err := loadWebAuthnUser(c.Context(), &user)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if len(waUser.Credentials) >= maxPasskeysPerUser {
+		return utils.ErrorResponse(c, 400, "Passkey limit reached, remove an unused passkey first")
+	}
+
+	// Passkey-only login needs a discoverable credential, and excluding the
+	// existing ones stops an authenticator registering twice
+	creation, session, err := config.WebAuthn.BeginRegistration(
+		waUser,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(waUser.Credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if err := saveWebAuthnChallenge(c.Context(), models.WebAuthnCeremonyRegistration, &user.ID, name, session); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", creation)
+}
+
+// FinishPasskeyRegistration godoc
+// @Summary Finish Passkey Registration
+// @Description Verify the credential returned by navigator.credentials.create() and store it as a passkey. The body is the PublicKeyCredential serialized as JSON.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} utils.APIResponse{data=models.PasskeyResponse} "Passkey registered"
+// @Failure 400 {object} utils.APIResponse "Invalid credential or expired challenge"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 409 {object} utils.APIResponse "Passkey already registered"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/passkeys/register/finish [post]
+func FinishPasskeyRegistration(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(c.Body())
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid credential")
+	}
+
+	stored, err := consumeWebAuthnChallenge(c.Context(), bson.M{
+		"challenge": parsed.Response.CollectedClientData.Challenge,
+		"ceremony":  models.WebAuthnCeremonyRegistration,
+		"userId":    userId,
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Challenge expired, start the registration again")
+	}
+
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": userId}).Decode(&user); err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	waUser, err := loadWebAuthnUser(c.Context(), &user)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	credential, err := config.WebAuthn.CreateCredential(waUser, stored.Session, parsed)
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid credential")
+	}
+
+	passkey := models.WebAuthnCredential{
+		ID:           primitive.NewObjectID(),
+		UserID:       user.ID,
+		Name:         stored.Name,
+		CredentialID: credential.ID,
+		Credential:   *credential,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := database.GetCollection("webauthn_credentials").InsertOne(c.Context(), passkey); err != nil {
+		if isDuplicateKey(err, "credential_id_unique") {
+			return utils.ErrorResponse(c, 409, "Passkey already registered")
+		}
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	recordSecurityEvent(c, user.ID, models.SecurityEventPasskeyAdded)
+
+	return utils.SuccessResponse(c, 201, "Passkey registered", models.WebAuthnCredentialToPasskeyResponse(&passkey))
+}
+
+// GetPasskeys godoc
+// @Summary List Passkeys
+// @Description List the passkeys registered to the authenticated user
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.PasskeyResponse} "Registered passkeys"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/passkeys [get]
+func GetPasskeys(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+
+	cursor, err := database.GetCollection("webauthn_credentials").Find(
+		c.Context(),
+		bson.M{"userId": userId},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	passkeys := make([]models.PasskeyResponse, 0)
+	for cursor.Next(c.Context()) {
+		passkey := models.WebAuthnCredential{}
+		if err := cursor.Decode(&passkey); err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		passkeys = append(passkeys, models.WebAuthnCredentialToPasskeyResponse(&passkey))
+	}
+
+	return utils.SuccessResponse(c, 200, "", passkeys)
+}
+
+// DeletePasskey godoc
+// @Summary Remove Passkey
+// @Description Remove one of the authenticated user's passkeys. It can no longer be used to log in.
+// @Tags Account
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Passkey ID"
+// @Success 200 {object} utils.APIResponse "Passkey removed"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Not available to personal access tokens"
+// @Failure 404 {object} utils.APIResponse "Passkey not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/passkeys/{id} [delete]
+func DeletePasskey(c *fiber.Ctx) error {
+	userId, err := primitive.ObjectIDFromHex(fmt.Sprintf("%v", c.Locals("userId")))
+	if err != nil {
+		return utils.ErrorResponse(c, 401, "Bad request")
+	}
+	passkeyId, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.ErrorResponse(c, 404, "Passkey not found")
+	}
+
+	result, err := database.GetCollection("webauthn_credentials").DeleteOne(c.Context(), bson.M{"_id": passkeyId, "userId": userId})
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if result.DeletedCount == 0 {
+		return utils.ErrorResponse(c, 404, "Passkey not found")
+	}
+	recordSecurityEvent(c, userId, models.SecurityEventPasskeyRemoved)
+
+	return utils.SuccessResponse(c, 200, "Passkey removed", nil)
+}
+
+// BeginPasskeyLogin godoc
+// @Summary Start Passkey Login
+// @Description Create the options for navigator.credentials.get(). No username is needed: the authenticator offers the passkeys it holds for this site.
+// @Tags Account
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=protocol.CredentialAssertion} "Credential request options"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/login/passkey/begin [post]
+func BeginPasskeyLogin(c *fiber.Ctx) error {
+	assertion, session, err := config.WebAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationPreferred),
+	)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if err := saveWebAuthnChallenge(c.Context(), models.WebAuthnCeremonyLogin, nil, "", session); err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 200, "", assertion)
+}
+
+// FinishPasskeyLogin godoc
+// @Summary Finish Passkey Login
+// @Description Verify the assertion returned by navigator.credentials.get() and log in. The body is the PublicKeyCredential serialized as JSON. A passkey that did not verify the user still needs the two-factor step on accounts that have it enabled.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Success 201 {object} utils.APIResponse{data=models.TokenPairResponse} "Logged In"
+// @Success 202 {object} utils.APIResponse{data=models.MFAChallengeResponse} "Two-factor code required"
+// @Failure 400 {object} utils.APIResponse "Invalid credential or expired challenge"
+// @Failure 401 {object} utils.APIResponse "Passkey not recognised"
+// @Failure 429 {object} utils.APIResponse "Too many failed attempts; see the Retry-After header"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /account/login/passkey/finish [post]
+func FinishPasskeyLogin(c *fiber.Ctx) error {
+	keys := []loginKey{ipLoginKey(c)}
+	wait, err := loginRetryAfter(c.Context(), keys)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	if wait > 0 {
+		setRetryAfter(c, wait)
+		return utils.ErrorResponse(c, 429, "Too many login attempts, please try again later")
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(c.Body())
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Invalid credential")
+	}
+
+	stored, err := consumeWebAuthnChallenge(c.Context(), bson.M{
+		"challenge": parsed.Response.CollectedClientData.Challenge,
+		"ceremony":  models.WebAuthnCeremonyLogin,
+	})
+	if err != nil {
+		return utils.ErrorResponse(c, 400, "Challenge expired, start the login again")
+	}
+
+	// The user handle the authenticator returns is the ObjectID we gave it
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		if len(userHandle) != len(primitive.ObjectID{}) {
+			return nil, errors.New("unknown user handle")
+		}
+		var userID primitive.ObjectID
+		copy(userID[:], userHandle)
+		user := models.User{}
+		if err := database.GetCollection("users").FindOne(c.Context(), bson.M{"_id": userID}).Decode(&user); err != nil {
+			return nil, err
+		}
+		return loadWebAuthnUser(c.Context(), &user)
+	}
+
+	waUser, credential, err := config.WebAuthn.ValidatePasskeyLogin(findUser, stored.Session, parsed)
+	if err == nil && credential.Authenticator.CloneWarning {
+		err = errors.New("signature counter went backwards")
+	}
+	if err != nil {
+		if wait, _ := recordLoginFailure(c.Context(), keys); wait > 0 {
+			setRetryAfter(c, wait)
+		}
+		return utils.ErrorResponse(c, 401, "Passkey not recognised")
+	}
+	user := waUser.(*models.WebAuthnUser).User
+
+	// Keep the signature counter and backup flags current for the next login
+	now := time.Now()
+	_, err = database.GetCollection("webauthn_credentials").UpdateOne(
+		c.Context(),
+		bson.M{"userId": user.ID, "credentialId": credential.ID},
+		bson.M{"$set": bson.M{"credential": *credential, "lastUsedAt": now}},
+	)
+	if err != nil {
+		log.Printf("could not update passkey of %s: %v", user.ID.Hex(), err)
+	}
+
+	// A passkey that verified the user is already two factors
+	if user.MFAEnabled && !credential.Flags.UserVerified {
+		challenge, err := issueMFAChallenge(user)
+		if err != nil {
+			return utils.ErrorResponse(c, 500, "Internal server error")
+		}
+		return utils.SuccessResponse(c, 202, "Two-factor code required", challenge)
+	}
+
+	session, err := createSession(c, user)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+	tokens, err := issueTokenPair(c.Context(), user, session.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, 500, "Internal server error")
+	}
+
+	return utils.SuccessResponse(c, 201, "Logged In", tokens)
+}
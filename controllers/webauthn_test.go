@@ -0,0 +1,249 @@
+package controllers_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAppURL = "https://voxa.example"
+
+// Authenticator data flags
+const (
+	flagUserPresent        = 0x01
+	flagUserVerified       = 0x04
+	flagAttestedCredential = 0x40
+)
+
+var b64 = base64.RawURLEncoding
+
+// softAuthenticator is a software passkey: an ES256 key pair that answers
+// create and get ceremonies the way a browser and platform authenticator
+// would, with "none" attestation.
+type softAuthenticator struct {
+	t            *testing.T
+	origin       string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 32)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	return &softAuthenticator{t: t, origin: testAppURL, key: key, credentialID: credentialID}
+}
+
+type ceremonyOptions struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		RPID      string `json:"rpId"`
+		RP        struct {
+			ID string `json:"id"`
+		} `json:"rp"`
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	} `json:"publicKey"`
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.origin,
+		"crossOrigin": false,
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *softAuthenticator) authenticatorData(rpID string, flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// create answers navigator.credentials.create() for options.
+func (a *softAuthenticator) create(options json.RawMessage) json.RawMessage {
+	parsed := ceremonyOptions{}
+	require.NoError(a.t, json.Unmarshal(options, &parsed))
+	userHandle, err := b64.DecodeString(parsed.PublicKey.User.ID)
+	require.NoError(a.t, err)
+	a.userHandle = userHandle
+
+	encMode, err := cbor.CTAP2EncOptions().EncMode()
+	require.NoError(a.t, err)
+	publicKey, err := encMode.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(a.t, err)
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := encMode.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(parsed.PublicKey.RP.ID, flagUserPresent|flagUserVerified|flagAttestedCredential, attested),
+	})
+	require.NoError(a.t, err)
+
+	return a.marshal(map[string]interface{}{
+		"clientDataJSON":    b64.EncodeToString(a.clientData("webauthn.create", parsed.PublicKey.Challenge)),
+		"attestationObject": b64.EncodeToString(attestation),
+	})
+}
+
+// get answers navigator.credentials.get() for options.
+func (a *softAuthenticator) get(options json.RawMessage) json.RawMessage {
+	parsed := ceremonyOptions{}
+	require.NoError(a.t, json.Unmarshal(options, &parsed))
+
+	a.signCount++
+	authData := a.authenticatorData(parsed.PublicKey.RPID, flagUserPresent|flagUserVerified, nil)
+	clientData := a.clientData("webauthn.get", parsed.PublicKey.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	return a.marshal(map[string]interface{}{
+		"clientDataJSON":    b64.EncodeToString(clientData),
+		"authenticatorData": b64.EncodeToString(authData),
+		"signature":         b64.EncodeToString(signature),
+		"userHandle":        b64.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) marshal(response map[string]interface{}) json.RawMessage {
+	credential, err := json.Marshal(map[string]interface{}{
+		"id":                     b64.EncodeToString(a.credentialID),
+		"rawId":                  b64.EncodeToString(a.credentialID),
+		"type":                   "public-key",
+		"response":               response,
+		"clientExtensionResults": map[string]interface{}{},
+	})
+	require.NoError(a.t, err)
+	return credential
+}
+
+func initTestWebAuthn(t *testing.T) {
+	t.Setenv("APP_URL", testAppURL)
+	t.Setenv("WEBAUTHN_RP_ORIGINS", "")
+	t.Setenv("WEBAUTHN_RP_ID", "")
+	require.NoError(t, config.InitWebAuthn())
+}
+
+// registerPasskey runs the registration ceremony for the session behind
+// token and returns the finish status.
+func (s *testServer) registerPasskey(token string, authenticator *softAuthenticator) int {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/account/passkeys/register/begin", token, models.BeginPasskeyRegistrationRequestDTO{Name: "Laptop"})
+	require.Equal(s.t, 200, status, res.Message)
+	status, _ = s.do(http.MethodPost, "/account/passkeys/register/finish", token, authenticator.create(res.Data))
+	return status
+}
+
+// passkeyLogin runs the login ceremony and returns the finish status and
+// response.
+func (s *testServer) passkeyLogin(authenticator *softAuthenticator) (int, apiResponse) {
+	s.t.Helper()
+	status, res := s.do(http.MethodPost, "/account/login/passkey/begin", "", nil)
+	require.Equal(s.t, 200, status, res.Message)
+	return s.do(http.MethodPost, "/account/login/passkey/finish", "", authenticator.get(res.Data))
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	s := newTestServer(t)
+	initTestWebAuthn(t)
+	s.register("liam", "", "correct horse")
+	token := s.login("liam", "correct horse")
+
+	authenticator := newSoftAuthenticator(t)
+	require.Equal(t, 201, s.registerPasskey(token, authenticator))
+
+	status, res := s.do(http.MethodGet, "/account/passkeys", token, nil)
+	require.Equal(t, 200, status)
+	passkeys := []models.PasskeyResponse{}
+	require.NoError(t, json.Unmarshal(res.Data, &passkeys))
+	require.Len(t, passkeys, 1)
+	assert.Equal(t, "Laptop", passkeys[0].Name)
+
+	// The same authenticator can't be registered twice
+	assert.NotEqual(t, 201, s.registerPasskey(token, authenticator))
+
+	status, res = s.passkeyLogin(authenticator)
+	require.Equal(t, 201, status, res.Message)
+	tokens := models.TokenPairResponse{}
+	require.NoError(t, json.Unmarshal(res.Data, &tokens))
+	status, _ = s.do(http.MethodGet, "/account/current-user", tokens.Token, nil)
+	assert.Equal(t, 201, status)
+}
+
+func TestPasskeyRegistrationRejectsForeignOrigin(t *testing.T) {
+	s := newTestServer(t)
+	initTestWebAuthn(t)
+	s.register("mia", "", "correct horse")
+	token := s.login("mia", "correct horse")
+
+	authenticator := newSoftAuthenticator(t)
+	authenticator.origin = "https://phishing.example"
+	assert.Equal(t, 400, s.registerPasskey(token, authenticator))
+}
+
+func TestPasskeyLoginRejects(t *testing.T) {
+	s := newTestServer(t)
+	initTestWebAuthn(t)
+	s.register("noah", "", "correct horse")
+	token := s.login("noah", "correct horse")
+	authenticator := newSoftAuthenticator(t)
+	require.Equal(t, 201, s.registerPasskey(token, authenticator))
+
+	t.Run("replayed assertion", func(t *testing.T) {
+		status, res := s.do(http.MethodPost, "/account/login/passkey/begin", "", nil)
+		require.Equal(t, 200, status)
+		assertion := authenticator.get(res.Data)
+		status, _ = s.do(http.MethodPost, "/account/login/passkey/finish", "", assertion)
+		require.Equal(t, 201, status)
+		status, _ = s.do(http.MethodPost, "/account/login/passkey/finish", "", assertion)
+		assert.Equal(t, 400, status)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		impostor := newSoftAuthenticator(t)
+		impostor.credentialID, impostor.userHandle = authenticator.credentialID, authenticator.userHandle
+		status, _ := s.passkeyLogin(impostor)
+		assert.Equal(t, 401, status)
+	})
+
+	t.Run("signature counter going backwards", func(t *testing.T) {
+		authenticator.signCount = 0
+		status, _ := s.passkeyLogin(authenticator)
+		assert.Equal(t, 401, status)
+	})
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying Voxa access tokens, selected by the token's kid header
+// @Tags WellKnown
+// @Produce json
+// @Success 200 {object} utils.JWKSet "Key set"
+// @Router /.well-known/jwks.json [get]
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.Status(200).JSON(utils.PublicJWKS())
+}
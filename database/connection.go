@@ -14,6 +14,10 @@ import (
 
 var DB *mongo.Database
 
+// UsernameCollation compares usernames case-insensitively. Queries on
+// username must pass it to match, and to use, the unique index.
+var UsernameCollation = &options.Collation{Locale: "en", Strength: 2}
+
 func ConnectMongoDB() {
 	uri := os.Getenv("MONGO_URI")
 	clientOptions := options.Client().ApplyURI(uri)
@@ -33,21 +37,342 @@ func ConnectMongoDB() {
 	DB = client.Database("voxa_temp")
 	fmt.Println("✅ Connected to MongoDB!")
 
+	EnsureIndexes()
+}
+
+// EnsureIndexes creates the indexes every collection in DB relies on.
+// Failures are logged rather than fatal, so the server still starts.
+func EnsureIndexes() {
 	// Ensure unique indexes for users collection: email and username
 	usersColl := DB.Collection("users")
 	// Create index models
 
 	usernameIndex := mongo.IndexModel{
-		Keys:    bson.D{{Key: "username", Value: 1}},
-		Options: options.Index().SetUnique(true).SetName("username_unique"),
+		Keys: bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("username_ci_unique").
+			SetCollation(UsernameCollation),
+	}
+
+	// Only verified addresses are unique: anyone can type in an address, so
+	// an unverified one must not block its owner from using it elsewhere
+	emailIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("verified_email_unique").
+			SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}, "emailVerified": true}),
 	}
 
 	// Create indexes with a context timeout
 	ctxIdx, cancelIdx := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelIdx()
-	if _, err := usersColl.Indexes().CreateMany(ctxIdx, []mongo.IndexModel{usernameIndex}); err != nil {
+	if _, err := usersColl.Indexes().CreateMany(ctxIdx, []mongo.IndexModel{usernameIndex, emailIndex}); err != nil {
 		// Index creation failure should not panic the app, but log it for debugging
 		log.Printf("warning: could not create user indexes: %v", err)
+	} else {
+		// Superseded by username_ci_unique and verified_email_unique. Only
+		// dropped once those exist, so the old guarantees hold until then.
+		_, _ = usersColl.Indexes().DropOne(ctxIdx, "username_unique")
+		_, _ = usersColl.Indexes().DropOne(ctxIdx, "email_unique")
+	}
+
+	// Refresh tokens are looked up by hash, revoked by family and expire via TTL
+	refreshTokensColl := DB.Collection("refresh_tokens")
+	refreshTokenIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "familyId", Value: 1}},
+			Options: options.Index().SetName("family_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := refreshTokensColl.Indexes().CreateMany(ctxIdx, refreshTokenIndexes); err != nil {
+		log.Printf("warning: could not create refresh token indexes: %v", err)
+	}
+
+	// Sessions are listed per user and dropped once they expire
+	sessionsColl := DB.Collection("sessions")
+	sessionIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "lastSeenAt", Value: -1}},
+			Options: options.Index().SetName("user_last_seen"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := sessionsColl.Indexes().CreateMany(ctxIdx, sessionIndexes); err != nil {
+		log.Printf("warning: could not create session indexes: %v", err)
+	}
+
+	// Password reset tokens are looked up by hash and expire via TTL
+	passwordResetsColl := DB.Collection("password_resets")
+	passwordResetIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetName("user_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := passwordResetsColl.Indexes().CreateMany(ctxIdx, passwordResetIndexes); err != nil {
+		log.Printf("warning: could not create password reset indexes: %v", err)
+	}
+
+	// Social login: pending states expire, and each external subject links once
+	oauthStatesColl := DB.Collection("oauth_states")
+	oauthStateIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "stateHash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("state_hash_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := oauthStatesColl.Indexes().CreateMany(ctxIdx, oauthStateIndexes); err != nil {
+		log.Printf("warning: could not create oauth state indexes: %v", err)
+	}
+
+	identitiesColl := DB.Collection("user_identities")
+	identityIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("provider_subject_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetName("user_id"),
+		},
+	}
+	if _, err := identitiesColl.Indexes().CreateMany(ctxIdx, identityIndexes); err != nil {
+		log.Printf("warning: could not create user identity indexes: %v", err)
+	}
+
+	// Old usernames keep resolving to their account until the alias expires
+	usernameAliasesColl := DB.Collection("username_aliases")
+	usernameAliasIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("username_ci_unique").
+				SetCollation(UsernameCollation),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetName("user_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := usernameAliasesColl.Indexes().CreateMany(ctxIdx, usernameAliasIndexes); err != nil {
+		log.Printf("warning: could not create username alias indexes: %v", err)
+	}
+
+	// Cloudinary assets waiting to be destroyed are picked oldest-due first
+	mediaDeletionsColl := DB.Collection("media_deletions")
+	mediaDeletionIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "nextAttemptAt", Value: 1}},
+			Options: options.Index().SetName("next_attempt_at"),
+		},
+	}
+	if _, err := mediaDeletionsColl.Indexes().CreateMany(ctxIdx, mediaDeletionIndexes); err != nil {
+		log.Printf("warning: could not create media deletion indexes: %v", err)
+	}
+
+	// Data exports are listed per user and claimed by the worker in order
+	dataExportsColl := DB.Collection("data_exports")
+	dataExportIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("user_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+			Options: options.Index().SetName("status_created_at"),
+		},
+	}
+	if _, err := dataExportsColl.Indexes().CreateMany(ctxIdx, dataExportIndexes); err != nil {
+		log.Printf("warning: could not create data export indexes: %v", err)
+	}
+
+	// Personal access tokens are looked up by hash and listed per user
+	accessTokensColl := DB.Collection("personal_access_tokens")
+	accessTokenIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("token_hash_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("user_created_at"),
+		},
+	}
+	if _, err := accessTokensColl.Indexes().CreateMany(ctxIdx, accessTokenIndexes); err != nil {
+		log.Printf("warning: could not create personal access token indexes: %v", err)
+	}
+
+	// Passkeys are listed per user; a credential ID can only belong to one
+	webauthnCredentialsColl := DB.Collection("webauthn_credentials")
+	webauthnCredentialIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "credentialId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("credential_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("user_created_at"),
+		},
+	}
+	if _, err := webauthnCredentialsColl.Indexes().CreateMany(ctxIdx, webauthnCredentialIndexes); err != nil {
+		log.Printf("warning: could not create webauthn credential indexes: %v", err)
+	}
+
+	// Ceremony challenges live until finished or for a few minutes
+	webauthnChallengesColl := DB.Collection("webauthn_challenges")
+	webauthnChallengeIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "challenge", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("challenge_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := webauthnChallengesColl.Indexes().CreateMany(ctxIdx, webauthnChallengeIndexes); err != nil {
+		log.Printf("warning: could not create webauthn challenge indexes: %v", err)
+	}
+
+	// Inbox listings page through one owner's messages by date
+	messagesColl := DB.Collection("messages")
+	messageIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "ownerId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("owner_id_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "ownerId", Value: 1}, {Key: "promptId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("owner_prompt_created_at"),
+		},
+		{
+			// Senders find their thread by the hash of their reply token
+			Keys: bson.D{{Key: "senderTokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("sender_token_hash_unique").
+				SetPartialFilterExpression(bson.M{"senderTokenHash": bson.M{"$exists": true}}),
+		},
+		{
+			// The trash view pages by deletion date
+			Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "deletedAt", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("owner_deleted_at").
+				SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		},
+		{
+			// The purger looks for messages trashed long enough ago
+			Keys: bson.D{{Key: "deletedAt", Value: 1}},
+			Options: options.Index().SetName("deleted_at").
+				SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		},
+	}
+	if _, err := messagesColl.Indexes().CreateMany(ctxIdx, messageIndexes); err != nil {
+		log.Printf("warning: could not create message indexes: %v", err)
+	} else {
+		// Superseded indexes from when messages were keyed by username
+		for _, name := range []string{"owner_created_at", "owner_username_created_at"} {
+			_, _ = messagesColl.Indexes().DropOne(ctxIdx, name)
+		}
+	}
+
+	// One answer per message; the public feed pages by publish date
+	answersColl := DB.Collection("answers")
+	answerIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "messageId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("message_id_unique"),
+		},
+		{
+			Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "publishedAt", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("owner_published_at").
+				SetPartialFilterExpression(bson.M{"publishedAt": bson.M{"$exists": true}}),
+		},
+	}
+	if _, err := answersColl.Indexes().CreateMany(ctxIdx, answerIndexes); err != nil {
+		log.Printf("warning: could not create answer indexes: %v", err)
+	}
+
+	// Thread replies are read per message in the order they were sent
+	messageRepliesColl := DB.Collection("message_replies")
+	messageReplyIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "messageId", Value: 1}, {Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("message_created_at"),
+		},
+	}
+	if _, err := messageRepliesColl.Indexes().CreateMany(ctxIdx, messageReplyIndexes); err != nil {
+		log.Printf("warning: could not create message reply indexes: %v", err)
+	}
+
+	// Share links find prompts by slug; each user has one default prompt
+	promptsColl := DB.Collection("prompts")
+	promptIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("slug_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "position", Value: 1}},
+			Options: options.Index().SetName("user_position"),
+		},
+		{
+			Keys: bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("user_default_unique").
+				SetPartialFilterExpression(bson.M{"isDefault": true}),
+		},
+	}
+	if _, err := promptsColl.Indexes().CreateMany(ctxIdx, promptIndexes); err != nil {
+		log.Printf("warning: could not create prompt indexes: %v", err)
+	}
+
+	// Failed login counters are keyed by _id and forgotten once their window ends
+	loginAttemptsColl := DB.Collection("login_attempts")
+	loginAttemptIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	}
+	if _, err := loginAttemptsColl.Indexes().CreateMany(ctxIdx, loginAttemptIndexes); err != nil {
+		log.Printf("warning: could not create login attempt indexes: %v", err)
+	}
+
+	// Security events are listed per user, newest first, and kept for 90 days
+	securityEventsColl := DB.Collection("security_events")
+	securityEventIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("user_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(90 * 24 * 60 * 60).SetName("created_at_ttl"),
+		},
+	}
+	if _, err := securityEventsColl.Indexes().CreateMany(ctxIdx, securityEventIndexes); err != nil {
+		log.Printf("warning: could not create security event indexes: %v", err)
 	}
 }
 
@@ -0,0 +1,50 @@
+// Package databasetest gives tests a throwaway MongoDB database.
+package databasetest
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Open points database.DB at a new, uniquely named database on the server
+// at MONGODB_TEST_URI, with every index in place, and drops it when the test
+// ends. Tests that call it are skipped when MONGODB_TEST_URI is unset.
+func Open(t testing.TB) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("pinging MongoDB: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = client.Database("voxa_test_" + primitive.NewObjectID().Hex())
+	database.EnsureIndexes()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := database.DB.Drop(ctx); err != nil {
+			t.Logf("dropping test database: %v", err)
+		}
+		_ = client.Disconnect(ctx)
+		database.DB = previous
+	})
+	return database.DB
+}
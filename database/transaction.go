@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// illegalOperationCode is what a standalone server answers when asked to
+// start a transaction.
+const illegalOperationCode = 20
+
+// RunInTransaction runs fn inside a transaction. Standalone servers, as used
+// in development, have no transactions; there fn runs without one, so it
+// should order its writes to be safe to retry after a partial failure.
+func RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := DB.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode {
+		return fn(ctx)
+	}
+	return err
+}
@@ -0,0 +1,414 @@
+package jobs
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	dataExportInterval    = 30 * time.Second
+	dataExportLease       = 15 * time.Minute
+	dataExportMaxAttempts = 3
+	// dataExportRetryDelay is the wait after a first failed attempt; it
+	// doubles with each further one
+	dataExportRetryDelay = 5 * time.Minute
+	// DataExportRetention is how long a finished archive can be downloaded
+	DataExportRetention = 7 * 24 * time.Hour
+	// dataExportEmailLinkTTL is how long the emailed download link works
+	dataExportEmailLinkTTL = 24 * time.Hour
+	dataExportFolder       = "Voxa_exports"
+	maxExportAudioSize     = 50 << 20
+	// cloudinaryDeliveryHost serves every stored audio file
+	cloudinaryDeliveryHost = "res.cloudinary.com"
+)
+
+var (
+	dataExportWake = make(chan struct{}, 1)
+	audioClient    = &http.Client{
+		Timeout: 60 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+// TriggerDataExports asks the worker to look for new exports now.
+func TriggerDataExports() {
+	select {
+	case dataExportWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartDataExports runs the worker that builds export archives and removes
+// them once they expire.
+func StartDataExports() {
+	go func() {
+		ticker := time.NewTicker(dataExportInterval)
+		defer ticker.Stop()
+		for {
+			runDataExports(context.Background())
+			expireDataExports(context.Background())
+			select {
+			case <-ticker.C:
+			case <-dataExportWake:
+			}
+		}
+	}()
+}
+
+// SignedExportURL returns a download link for a ready export that stops
+// working after ttl, or when the export expires if that is sooner.
+func SignedExportURL(export *models.DataExport, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+	if export.ExpiresAt != nil && export.ExpiresAt.Before(expiresAt) {
+		expiresAt = *export.ExpiresAt
+	}
+	link, err := config.Cloud.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+		PublicID:     export.PublicID,
+		DeliveryType: "private",
+		ResourceType: api.File,
+		Attachment:   "true",
+		ExpiresAt:    &expiresAt,
+	})
+	return link, expiresAt, err
+}
+
+func runDataExports(ctx context.Context) {
+	collection := database.GetCollection("data_exports")
+	for {
+		now := time.Now()
+		export := models.DataExport{}
+		// Also pick up processing exports whose worker died mid-way. Failed
+		// attempts back off, so a broken export can't spin the worker.
+		err := collection.FindOneAndUpdate(
+			ctx,
+			bson.M{"$or": []bson.M{
+				{"status": models.DataExportPending, "notBefore": bson.M{"$exists": false}},
+				{"status": models.DataExportPending, "notBefore": bson.M{"$lte": now}},
+				{"status": models.DataExportProcessing, "leaseUntil": bson.M{"$lt": now}},
+			}},
+			bson.M{
+				"$set": bson.M{"status": models.DataExportProcessing, "leaseUntil": now.Add(dataExportLease)},
+				"$inc": bson.M{"attempts": 1},
+			},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(&export)
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		if err != nil {
+			log.Printf("data export: %v", err)
+			return
+		}
+
+		if buildErr := processDataExport(ctx, &export); buildErr != nil {
+			log.Printf("data export %s attempt %d failed: %v", export.ID.Hex(), export.Attempts, buildErr)
+			set := bson.M{
+				"status":    models.DataExportPending,
+				"error":     buildErr.Error(),
+				"notBefore": time.Now().Add(dataExportRetryDelay << (export.Attempts - 1)),
+			}
+			if export.Attempts >= dataExportMaxAttempts {
+				set = bson.M{"status": models.DataExportFailed, "error": buildErr.Error()}
+			}
+			if _, err := collection.UpdateOne(
+				ctx,
+				bson.M{"_id": export.ID, "status": models.DataExportProcessing},
+				bson.M{"$set": set, "$unset": bson.M{"leaseUntil": ""}},
+			); err != nil {
+				log.Printf("data export: %v", err)
+			}
+		}
+	}
+}
+
+// processDataExport builds, uploads and publishes one export.
+func processDataExport(ctx context.Context, export *models.DataExport) error {
+	user := models.User{}
+	if err := database.GetCollection("users").FindOne(ctx, bson.M{"_id": export.UserID}).Decode(&user); err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+
+	archive, err := os.CreateTemp("", "voxa_export_*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := writeExportArchive(ctx, archive, &user); err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	uploadResult, err := config.Cloud.Upload.Upload(ctx, archive, uploader.UploadParams{
+		PublicID:     export.ID.Hex() + ".zip",
+		Folder:       dataExportFolder,
+		ResourceType: api.File,
+		Type:         "private",
+	})
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	if uploadResult.Error.Message != "" {
+		return fmt.Errorf("upload: %s", uploadResult.Error.Message)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(DataExportRetention)
+	result, err := database.GetCollection("data_exports").UpdateOne(
+		ctx,
+		bson.M{"_id": export.ID, "status": models.DataExportProcessing},
+		bson.M{
+			"$set": bson.M{
+				"status":      models.DataExportReady,
+				"publicId":    uploadResult.PublicID,
+				"completedAt": now,
+				"expiresAt":   expiresAt,
+			},
+			"$unset": bson.M{"leaseUntil": "", "error": ""},
+		},
+	)
+	if err == nil && result.MatchedCount == 0 {
+		// The account was deleted while we worked; don't leave the archive behind
+		err = EnqueueMediaDeletions(ctx, []string{uploadResult.PublicID}, api.File, "private")
+		TriggerMediaCleanup()
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	export.Status = models.DataExportReady
+	export.PublicID = uploadResult.PublicID
+	export.ExpiresAt = &expiresAt
+	if user.Email != "" && user.EmailVerified {
+		if err := sendDataExportReady(ctx, &user, export); err != nil {
+			log.Printf("data export %s: ready email failed: %v", export.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// writeExportArchive writes the audio of every message and a manifest.json
+// describing the profile, prompts, messages, answers and thread replies
+// into w as a ZIP.
+func writeExportArchive(ctx context.Context, w io.Writer, user *models.User) error {
+	cursor, err := database.GetCollection("messages").Find(
+		ctx,
+		bson.M{"ownerId": user.ID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	messages := make([]models.ExportMessage, 0)
+	for cursor.Next(ctx) {
+		message := models.Message{}
+		if err := cursor.Decode(&message); err != nil {
+			return err
+		}
+		messages = append(messages, models.ExportMessage{Message: message})
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	answerCursor, err := database.GetCollection("answers").Find(ctx, bson.M{"ownerId": user.ID})
+	if err != nil {
+		return err
+	}
+	answers := make(map[primitive.ObjectID]*models.Answer)
+	for answerCursor.Next(ctx) {
+		answer := &models.Answer{}
+		if err := answerCursor.Decode(answer); err != nil {
+			return err
+		}
+		answers[answer.MessageID] = answer
+	}
+	if err := answerCursor.Err(); err != nil {
+		return err
+	}
+
+	replyCursor, err := database.GetCollection("message_replies").Find(
+		ctx,
+		bson.M{"ownerId": user.ID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	replies := make(map[primitive.ObjectID][]models.MessageReply)
+	for replyCursor.Next(ctx) {
+		reply := models.MessageReply{}
+		if err := replyCursor.Decode(&reply); err != nil {
+			return err
+		}
+		replies[reply.MessageID] = append(replies[reply.MessageID], reply)
+	}
+	if err := replyCursor.Err(); err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messages[i].Answer = answers[messages[i].ID]
+		messages[i].Replies = replies[messages[i].ID]
+	}
+
+	promptCursor, err := database.GetCollection("prompts").Find(
+		ctx,
+		bson.M{"userId": user.ID},
+		options.Find().SetSort(bson.D{{Key: "position", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	prompts := make([]models.Prompt, 0)
+	if err := promptCursor.All(ctx, &prompts); err != nil {
+		return err
+	}
+
+	zipWriter := zip.NewWriter(w)
+	for i := range messages {
+		if messages[i].Audio == nil || messages[i].Audio.URL == "" {
+			continue
+		}
+		// One missing file shouldn't sink the whole export
+		path := "audio/" + messages[i].ID.Hex() + ".mp3"
+		if err := addExportAudio(ctx, zipWriter, path, messages[i].Audio.URL); err != nil {
+			messages[i].AudioError = err.Error()
+			continue
+		}
+		messages[i].AudioFile = path
+	}
+
+	manifest, err := zipWriter.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(manifest)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(models.ExportManifest{
+		ExportedAt: time.Now(),
+		Profile:    models.UserToExportProfile(user),
+		Prompts:    prompts,
+		Messages:   messages,
+	}); err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+func addExportAudio(ctx context.Context, zipWriter *zip.Writer, path, audioURL string) error {
+	// Stored URLs come from Cloudinary, but never fetch from anywhere else;
+	// redirects are not followed, so they fail on the status check below
+	if !isCloudinaryURL(audioURL) {
+		return fmt.Errorf("unsupported audio url")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := audioClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	// Read it whole first so a broken download never leaves half a file in
+	// the archive
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxExportAudioSize+1))
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if len(audio) > maxExportAudioSize {
+		return fmt.Errorf("audio file too large")
+	}
+
+	entry, err := zipWriter.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(audio)
+	return err
+}
+
+func isCloudinaryURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && parsed.Scheme == "https" && parsed.Host == cloudinaryDeliveryHost
+}
+
+func sendDataExportReady(ctx context.Context, user *models.User, export *models.DataExport) error {
+	link, _, err := SignedExportURL(export, dataExportEmailLinkTTL)
+	if err != nil {
+		return err
+	}
+	return config.Mailer.Send(ctx, utils.Mail{
+		To:      user.Email,
+		Subject: "Your Voxa data export is ready",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYour data export is ready. Download it within 24 hours using the link below; after that, request a new link from the app until %s.\n\n%s\n",
+			user.Username, export.ExpiresAt.Format("2 January 2006"), link,
+		),
+	})
+}
+
+// expireDataExports destroys archives past their retention and drops
+// their records.
+func expireDataExports(ctx context.Context) {
+	collection := database.GetCollection("data_exports")
+	cursor, err := collection.Find(ctx, bson.M{
+		"status":    models.DataExportReady,
+		"expiresAt": bson.M{"$lte": time.Now()},
+	})
+	if err != nil {
+		log.Printf("data export expiry: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		export := models.DataExport{}
+		if err := cursor.Decode(&export); err != nil {
+			log.Printf("data export expiry: %v", err)
+			return
+		}
+		// Queue the asset before forgetting it, so a crash in between
+		// leaves an extra queue entry rather than an orphaned archive
+		if err := EnqueueMediaDeletions(ctx, []string{export.PublicID}, api.File, "private"); err != nil {
+			log.Printf("data export expiry: could not queue %s: %v", export.PublicID, err)
+			continue
+		}
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": export.ID}); err != nil {
+			log.Printf("data export expiry: %v", err)
+		}
+		TriggerMediaCleanup()
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/database/databasetest"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFailedDataExportBacksOff(t *testing.T) {
+	databasetest.Open(t)
+	ctx := context.Background()
+	collection := database.GetCollection("data_exports")
+
+	// The user is gone, so every attempt fails
+	export := models.DataExport{
+		ID:        primitive.NewObjectID(),
+		UserID:    primitive.NewObjectID(),
+		Status:    models.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	_, err := collection.InsertOne(ctx, export)
+	require.NoError(t, err)
+
+	runDataExports(ctx)
+	runDataExports(ctx)
+
+	stored := models.DataExport{}
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": export.ID}).Decode(&stored))
+	assert.Equal(t, models.DataExportPending, stored.Status)
+	assert.Equal(t, 1, stored.Attempts, "the retry waits for its back-off")
+	require.NotNil(t, stored.NotBefore)
+	assert.WithinDuration(t, time.Now().Add(dataExportRetryDelay), *stored.NotBefore, time.Minute)
+
+	// Once the back-off has passed it is tried again, and the wait doubles
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": export.ID}, bson.M{"$set": bson.M{"notBefore": time.Now()}})
+	require.NoError(t, err)
+	runDataExports(ctx)
+
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": export.ID}).Decode(&stored))
+	assert.Equal(t, 2, stored.Attempts)
+	assert.WithinDuration(t, time.Now().Add(2*dataExportRetryDelay), *stored.NotBefore, time.Minute)
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": export.ID}, bson.M{"$set": bson.M{"notBefore": time.Now()}})
+	require.NoError(t, err)
+	runDataExports(ctx)
+
+	require.NoError(t, collection.FindOne(ctx, bson.M{"_id": export.ID}).Decode(&stored))
+	assert.Equal(t, models.DataExportFailed, stored.Status)
+	assert.Equal(t, dataExportMaxAttempts, stored.Attempts)
+}
+
+func TestExportAudioOnlyFromCloudinary(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://res.cloudinary.com/voxa/video/upload/v1/Voxa_audio/a.mp3", true},
+		{"http://res.cloudinary.com/voxa/video/upload/v1/Voxa_audio/a.mp3", false},
+		{"https://res.cloudinary.com:8443/voxa/video/upload/a.mp3", false},
+		{"https://res.cloudinary.com.example.com/a.mp3", false},
+		{"https://user@169.254.169.254/latest/meta-data", false},
+		{"https://localhost/a.mp3", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isCloudinaryURL(tt.url), tt.url)
+	}
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/config"
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	mediaCleanupInterval = time.Minute
+	mediaCleanupBatch    = 50
+	// mediaCleanupLease keeps other replicas off an asset while one of them
+	// is talking to Cloudinary about it
+	mediaCleanupLease   = 5 * time.Minute
+	mediaCleanupMaxWait = 24 * time.Hour
+)
+
+var mediaCleanupWake = make(chan struct{}, 1)
+
+// EnqueueMediaDeletions records Cloudinary assets to destroy. Call it with
+// the same context, and so the same transaction, as the delete that
+// orphans them.
+func EnqueueMediaDeletions(ctx context.Context, publicIDs []string, resourceType, deliveryType string) error {
+	if len(publicIDs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, 0, len(publicIDs))
+	for _, publicID := range publicIDs {
+		docs = append(docs, models.MediaDeletion{
+			ID:            primitive.NewObjectID(),
+			PublicID:      publicID,
+			ResourceType:  resourceType,
+			DeliveryType:  deliveryType,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		})
+	}
+	_, err := database.GetCollection("media_deletions").InsertMany(ctx, docs)
+	return err
+}
+
+// TriggerMediaCleanup asks the worker to run now instead of at its next
+// tick, e.g. right after an account deletion commits.
+func TriggerMediaCleanup() {
+	select {
+	case mediaCleanupWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartMediaCleanup runs the worker that destroys queued Cloudinary assets,
+// backing off exponentially on assets that keep failing.
+func StartMediaCleanup() {
+	go func() {
+		ticker := time.NewTicker(mediaCleanupInterval)
+		defer ticker.Stop()
+		for {
+			runMediaCleanup(context.Background())
+			select {
+			case <-ticker.C:
+			case <-mediaCleanupWake:
+			}
+		}
+	}()
+}
+
+func runMediaCleanup(ctx context.Context) {
+	collection := database.GetCollection("media_deletions")
+	for i := 0; i < mediaCleanupBatch; i++ {
+		now := time.Now()
+		job := models.MediaDeletion{}
+		err := collection.FindOneAndUpdate(
+			ctx,
+			bson.M{"nextAttemptAt": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"nextAttemptAt": now.Add(mediaCleanupLease)}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}),
+		).Decode(&job)
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		if err != nil {
+			log.Printf("media cleanup: %v", err)
+			return
+		}
+
+		if destroyErr := destroyMedia(ctx, &job); destroyErr != nil {
+			job.Attempts++
+			wait := time.Minute << uint(min(job.Attempts, 11))
+			if wait > mediaCleanupMaxWait {
+				wait = mediaCleanupMaxWait
+			}
+			log.Printf("media cleanup: %s attempt %d failed: %v", job.PublicID, job.Attempts, destroyErr)
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{
+				"attempts":      job.Attempts,
+				"lastError":     destroyErr.Error(),
+				"nextAttemptAt": time.Now().Add(wait),
+			}}); err != nil {
+				log.Printf("media cleanup: %v", err)
+			}
+			continue
+		}
+
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": job.ID}); err != nil {
+			log.Printf("media cleanup: %v", err)
+		}
+	}
+}
+
+func destroyMedia(ctx context.Context, job *models.MediaDeletion) error {
+	result, err := config.Cloud.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     job.PublicID,
+		ResourceType: job.ResourceType,
+		Type:         job.DeliveryType,
+	})
+	if err != nil {
+		return err
+	}
+	// "not found" means an earlier attempt got there first
+	if result.Result != "ok" && result.Result != "not found" {
+		if result.Error.Message != "" {
+			return fmt.Errorf("cloudinary: %s", result.Error.Message)
+		}
+		return fmt.Errorf("cloudinary: unexpected result %q", result.Result)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MessageTrashRetention is how long a deleted message stays in the trash,
+	// where it can be restored, before it is purged
+	MessageTrashRetention = 30 * 24 * time.Hour
+	messagePurgeInterval  = time.Hour
+	messagePurgeBatch     = 200
+)
+
+// StartMessagePurge runs the worker that permanently deletes messages once
+// they have been in the trash for MessageTrashRetention.
+func StartMessagePurge() {
+	go func() {
+		ticker := time.NewTicker(messagePurgeInterval)
+		defer ticker.Stop()
+		for {
+			runMessagePurge(context.Background())
+			<-ticker.C
+		}
+	}()
+}
+
+func runMessagePurge(ctx context.Context) {
+	for {
+		cutoff := time.Now().Add(-MessageTrashRetention)
+		cursor, err := database.GetCollection("messages").Find(
+			ctx,
+			bson.M{"deletedAt": bson.M{"$lte": cutoff}},
+			options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(messagePurgeBatch),
+		)
+		if err != nil {
+			log.Printf("message purge: %v", err)
+			return
+		}
+		messageIDs := make([]primitive.ObjectID, 0, messagePurgeBatch)
+		for cursor.Next(ctx) {
+			message := models.Message{}
+			if err := cursor.Decode(&message); err != nil {
+				log.Printf("message purge: %v", err)
+				return
+			}
+			messageIDs = append(messageIDs, message.ID)
+		}
+		if err := cursor.Err(); err != nil {
+			log.Printf("message purge: %v", err)
+			return
+		}
+		if len(messageIDs) == 0 {
+			return
+		}
+
+		// Check deletedAt again in case a message was restored in between
+		var purged []primitive.ObjectID
+		err = database.RunInTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			purged, err = DeleteMessages(ctx, bson.M{
+				"_id":       bson.M{"$in": messageIDs},
+				"deletedAt": bson.M{"$lte": cutoff},
+			})
+			return err
+		})
+		if err != nil {
+			log.Printf("message purge: %v", err)
+			return
+		}
+		if len(purged) > 0 {
+			TriggerMediaCleanup()
+		}
+		if len(messageIDs) < messagePurgeBatch {
+			return
+		}
+	}
+}
+
+// DeleteMessages permanently removes the messages matching filter, with
+// their answers and thread replies, queues their Cloudinary audio for
+// destruction and returns their IDs. Run it in a transaction so the queue
+// and the delete commit together.
+func DeleteMessages(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	messageCollection := database.GetCollection("messages")
+	answerCollection := database.GetCollection("answers")
+
+	cursor, err := messageCollection.Find(
+		ctx,
+		filter,
+		options.Find().SetProjection(bson.M{"audio.publicId": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	messageIDs := make([]primitive.ObjectID, 0)
+	publicIDs := make([]string, 0)
+	for cursor.Next(ctx) {
+		message := models.Message{}
+		if err := cursor.Decode(&message); err != nil {
+			return nil, err
+		}
+		messageIDs = append(messageIDs, message.ID)
+		if message.Audio != nil && message.Audio.PublicID != "" {
+			publicIDs = append(publicIDs, message.Audio.PublicID)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if len(messageIDs) == 0 {
+		return messageIDs, nil
+	}
+
+	byMessageID := bson.M{"messageId": bson.M{"$in": messageIDs}}
+	cursor, err = answerCollection.Find(
+		ctx,
+		bson.M{"$and": []bson.M{byMessageID, {"audio.publicId": bson.M{"$nin": []interface{}{nil, ""}}}}},
+		options.Find().SetProjection(bson.M{"audio.publicId": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for cursor.Next(ctx) {
+		answer := models.Answer{}
+		if err := cursor.Decode(&answer); err != nil {
+			return nil, err
+		}
+		publicIDs = append(publicIDs, answer.Audio.PublicID)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// Queue first: if the delete fails afterwards, destroying audio of
+	// messages that still exist is better than leaking it forever
+	if err := EnqueueMediaDeletions(ctx, publicIDs, "video", ""); err != nil {
+		return nil, err
+	}
+	if _, err := answerCollection.DeleteMany(ctx, byMessageID); err != nil {
+		return nil, err
+	}
+	if _, err := database.GetCollection("message_replies").DeleteMany(ctx, byMessageID); err != nil {
+		return nil, err
+	}
+	if _, err := messageCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": messageIDs}}); err != nil {
+		return nil, err
+	}
+	return messageIDs, nil
+}
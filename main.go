@@ -10,6 +10,7 @@ package main
 // @description Enter your token with the "Bearer " prefix, e.g. "Bearer eyJhbGciOi..."
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -20,7 +21,10 @@ import (
 
 	"github.com/Investorharry19/voxa-golang-server/config"
 	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/jobs"
+	"github.com/Investorharry19/voxa-golang-server/migrations"
 	"github.com/Investorharry19/voxa-golang-server/routers"
+	"github.com/Investorharry19/voxa-golang-server/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 
@@ -54,8 +58,29 @@ func main() {
 	docs.SwaggerInfo.Host = host
 	docs.SwaggerInfo.Schemes = []string{scheme}
 
+	// Refuse to start without a usable JWT signing key
+	if err := utils.InitJWTKeys(); err != nil {
+		log.Fatal("JWT key error: ", err)
+	}
+	if err := utils.InitArgon2(); err != nil {
+		log.Fatal("Argon2 config error: ", err)
+	}
+	if err := utils.InitOIDCProviders(); err != nil {
+		log.Fatal("OAuth config error: ", err)
+	}
+	if err := config.InitWebAuthn(); err != nil {
+		log.Fatal("WebAuthn config error: ", err)
+	}
+
+	serverConfig, err := config.ProxyConfig()
+	if err != nil {
+		log.Fatal("Proxy config error: ", err)
+	}
+	// Leave room for a 5 MB avatar plus the multipart framing around it
+	serverConfig.BodyLimit = 6 << 20
+
 	// Initialize Fiber
-	app := fiber.New()
+	app := fiber.New(serverConfig)
 	app.Use(logger.New())
 
 	// Swagger endpoint
@@ -69,10 +94,25 @@ func main() {
 	// Routers
 	routers.UserRouter(app)
 	routers.MessageRouter(app)
+	routers.WellKnownRouter(app)
+	routers.AdminRouter(app)
+	routers.ProfileRouter(app)
 
 	// Config and DB
 	config.InitCloudinary()
+	if err := config.InitMailer(); err != nil {
+		log.Fatal("Mailer config error: ", err)
+	}
 	database.ConnectMongoDB()
+	if err := migrations.Run(context.Background()); err != nil {
+		log.Fatal("Migration error: ", err)
+	}
+	if err := config.InitLoginAttempts(); err != nil {
+		log.Fatal("Login attempt store error: ", err)
+	}
+	jobs.StartMediaCleanup()
+	jobs.StartDataExports()
+	jobs.StartMessagePurge()
 
 	// Start server
 	log.Printf("Server running on port %s (Swagger Host: %s)\n", port, host)
@@ -2,11 +2,20 @@ package middlewares
 
 import (
 	"strings"
+	"time"
 
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
 	"github.com/Investorharry19/voxa-golang-server/utils"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// lastSeenInterval throttles how often a session's lastSeenAt is written.
+const lastSeenInterval = time.Minute
+
 func RequireAuth(c *fiber.Ctx) error {
 	authHeader := c.Get("Authorization")
 	if authHeader == "" {
@@ -14,14 +23,71 @@ func RequireAuth(c *fiber.Ctx) error {
 	}
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if strings.HasPrefix(tokenString, utils.PersonalAccessTokenPrefix) {
+		return requireAccessToken(c, tokenString)
+	}
 	token, claims, err := utils.ValidateJWT(tokenString)
 	if err != nil || !token.Valid {
 		return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired token"})
 	}
+	// Single-purpose tokens (email links and the like) are not access tokens
+	if _, ok := claims["purpose"]; ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired token"})
+	}
+
+	userId, _ := claims["id"].(string)
+	sessionId, _ := claims["sid"].(string)
+	tokenVersion, _ := claims["ver"].(float64)
+
+	userObjectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired token"})
+	}
+	sessionObjectId, err := primitive.ObjectIDFromHex(sessionId)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired token"})
+	}
+
+	// The session must still be live and the token must carry the user's
+	// current token version, so revocations apply on the very next request.
+	now := time.Now()
+	session := models.Session{}
+	err = database.GetCollection("sessions").FindOne(c.Context(), bson.M{
+		"_id":       sessionObjectId,
+		"userId":    userObjectId,
+		"revokedAt": bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+	}).Decode(&session)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Session has been revoked"})
+	}
+
+	user := models.User{}
+	err = database.GetCollection("users").FindOne(
+		c.Context(),
+		bson.M{"_id": userObjectId},
+		options.FindOne().SetProjection(bson.M{"tokenVersion": 1, "role": 1}),
+	).Decode(&user)
+	if err != nil || user.TokenVersion != int(tokenVersion) {
+		return c.Status(401).JSON(fiber.Map{"error": "Session has been revoked"})
+	}
+
+	if now.Sub(session.LastSeenAt) > lastSeenInterval {
+		database.GetCollection("sessions").UpdateOne(
+			c.Context(),
+			bson.M{"_id": session.ID},
+			bson.M{"$set": bson.M{"lastSeenAt": now, "ip": c.IP()}},
+		)
+	}
 
 	// You can store token claims in Locals (for later handlers)
 
 	c.Locals("userId", claims["id"])
+	c.Locals("sessionId", sessionId)
+	c.Locals("tokenVersion", user.TokenVersion)
+	// The role claim is for clients; the stored role applies immediately
+	// after a change
+	c.Locals("role", user.EffectiveRole())
 
 	return c.Next()
 }
@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole lets through users whose role is min or a more privileged
+// one. Use after RequireAuth.
+func RequireRole(min string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !models.RoleAtLeast(role, min) {
+			return c.Status(403).JSON(fiber.Map{"error": "Insufficient permissions"})
+		}
+		return c.Next()
+	}
+}
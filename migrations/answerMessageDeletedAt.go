@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// answerMessageDeletedAtBatch is how many trashed messages are handled per
+// answers update.
+const answerMessageDeletedAtBatch = 1000
+
+// answerMessageDeletedAt flags the answers to messages already in the trash
+// with messageDeletedAt, which the public feed now filters on.
+func answerMessageDeletedAt(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("messages").Find(
+		ctx,
+		bson.M{"deletedAt": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"_id": 1, "deletedAt": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	writes := make([]mongo.WriteModel, 0, answerMessageDeletedAtBatch)
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		_, err := db.Collection("answers").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		writes = writes[:0]
+		return err
+	}
+	for cursor.Next(ctx) {
+		var message struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			DeletedAt time.Time          `bson:"deletedAt"`
+		}
+		if err := cursor.Decode(&message); err != nil {
+			return err
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"messageId": message.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"messageDeletedAt": message.DeletedAt}}))
+		if len(writes) == answerMessageDeletedAtBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,178 @@
+package migrations
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// messageOwnerID replaces each message's ownerUsername with the ID of the
+// account that holds that username, or held it before a rename. Messages
+// whose username belongs to no one are left as they are, owned by nobody,
+// rather than handed to whoever registers the name next. Their IDs are
+// listed in orphaned_messages; add a username alias pointing at the right
+// account and restart to have them picked up by retryOrphanedMessages.
+func messageOwnerID(ctx context.Context, db *mongo.Database) error {
+	messages := db.Collection("messages")
+
+	usernames, err := messages.Distinct(ctx, "ownerUsername", bson.M{"schemaVersion": 2})
+	if err != nil {
+		return err
+	}
+
+	for _, value := range usernames {
+		username, ok := value.(string)
+		if !ok {
+			continue
+		}
+		ownerID, err := resolveOwner(ctx, db, username)
+		if err == mongo.ErrNoDocuments {
+			if err := recordOrphans(ctx, db, username); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := claimMessages(ctx, db, username, ownerID); err != nil {
+			return err
+		}
+	}
+	return logOrphans(ctx, db)
+}
+
+// retryOrphanedMessages gives the messages messageOwnerID could not place
+// another chance, and files the ones it places under their owner's default
+// prompt as messagePromptID would have.
+func retryOrphanedMessages(ctx context.Context, db *mongo.Database) error {
+	orphans := db.Collection("orphaned_messages")
+
+	usernames, err := orphans.Distinct(ctx, "ownerUsername", bson.M{})
+	if err != nil {
+		return err
+	}
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	for _, value := range usernames {
+		username, ok := value.(string)
+		if !ok {
+			continue
+		}
+		ownerID, err := resolveOwner(ctx, db, username)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := claimMessages(ctx, db, username, ownerID); err != nil {
+			return err
+		}
+		if err := fileUnderDefaultPrompt(ctx, db, ownerID); err != nil {
+			return err
+		}
+		if _, err := orphans.DeleteMany(ctx, bson.M{"ownerUsername": username}); err != nil {
+			return err
+		}
+		log.Printf("migration: gave the orphaned messages of %q to account %s", username, ownerID.Hex())
+	}
+	return logOrphans(ctx, db)
+}
+
+// resolveOwner finds the account that holds username, case insensitively,
+// or else the one a username alias still points at. It returns
+// mongo.ErrNoDocuments when there is neither.
+func resolveOwner(ctx context.Context, db *mongo.Database, username string) (primitive.ObjectID, error) {
+	users := db.Collection("users")
+	user := models.User{}
+	err := users.FindOne(
+		ctx,
+		bson.M{"username": username},
+		options.FindOne().SetCollation(database.UsernameCollation),
+	).Decode(&user)
+	if err != mongo.ErrNoDocuments {
+		return user.ID, err
+	}
+
+	// An alias outlives its expiry until the TTL monitor removes it, and
+	// until then it still names the account that held the username
+	alias := models.UsernameAlias{}
+	if err := db.Collection("username_aliases").FindOne(
+		ctx,
+		bson.M{"username": username},
+		options.FindOne().SetCollation(database.UsernameCollation),
+	).Decode(&alias); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := users.FindOne(ctx, bson.M{"_id": alias.UserID}).Decode(&user); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return user.ID, nil
+}
+
+// claimMessages moves the version 2 messages sent to username to ownerID.
+func claimMessages(ctx context.Context, db *mongo.Database, username string, ownerID primitive.ObjectID) error {
+	_, err := db.Collection("messages").UpdateMany(
+		ctx,
+		bson.M{"schemaVersion": 2, "ownerUsername": username},
+		bson.M{
+			"$set":   bson.M{"ownerId": ownerID, "schemaVersion": 3},
+			"$unset": bson.M{"ownerUsername": ""},
+		},
+	)
+	return err
+}
+
+// recordOrphans lists the version 2 messages sent to username in
+// orphaned_messages.
+func recordOrphans(ctx context.Context, db *mongo.Database, username string) error {
+	cursor, err := db.Collection("messages").Find(
+		ctx,
+		bson.M{"schemaVersion": 2, "ownerUsername": username},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	found := make([]struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}, 0)
+	if err := cursor.All(ctx, &found); err != nil {
+		return err
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(found))
+	for _, message := range found {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": message.ID}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{"ownerUsername": username, "recordedAt": time.Now()}}).
+			SetUpsert(true))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err = db.Collection("orphaned_messages").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// logOrphans reports how many messages are still waiting for an owner.
+func logOrphans(ctx context.Context, db *mongo.Database) error {
+	count, err := db.Collection("orphaned_messages").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("migration: %d messages sent to usernames without an account are unowned; see orphaned_messages", count)
+	}
+	return nil
+}
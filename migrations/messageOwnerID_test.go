@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database/databasetest"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMessageOwnerID(t *testing.T) {
+	db := databasetest.Open(t)
+	ctx := context.Background()
+	messages := db.Collection("messages")
+
+	holder := primitive.NewObjectID()
+	renamed := primitive.NewObjectID()
+	_, err := db.Collection("users").InsertMany(ctx, []interface{}{
+		bson.M{"_id": holder, "username": "Quinn"},
+		bson.M{"_id": renamed, "username": "rosa"},
+	})
+	require.NoError(t, err)
+	_, err = db.Collection("username_aliases").InsertOne(ctx, models.UsernameAlias{
+		ID:        primitive.NewObjectID(),
+		Username:  "rosie",
+		UserID:    renamed,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	sentTo := map[string]primitive.ObjectID{}
+	for _, username := range []string{"quinn", "rosie", "sam"} {
+		id := primitive.NewObjectID()
+		sentTo[username] = id
+		_, err := messages.InsertOne(ctx, bson.M{"_id": id, "schemaVersion": 2, "ownerUsername": username})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, messageOwnerID(ctx, db))
+	require.NoError(t, messagePromptID(ctx, db))
+
+	stored := func(id primitive.ObjectID) bson.M {
+		message := bson.M{}
+		require.NoError(t, messages.FindOne(ctx, bson.M{"_id": id}).Decode(&message))
+		return message
+	}
+	assert.Equal(t, holder, stored(sentTo["quinn"])["ownerId"], "usernames match case insensitively")
+	assert.Equal(t, renamed, stored(sentTo["rosie"])["ownerId"], "aliases are followed")
+	assert.EqualValues(t, 2, stored(sentTo["sam"])["schemaVersion"])
+
+	orphans, err := db.Collection("orphaned_messages").CountDocuments(ctx, bson.M{"_id": sentTo["sam"], "ownerUsername": "sam"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, orphans)
+
+	// An alias added later hands the orphan over on the next start
+	_, err = db.Collection("username_aliases").InsertOne(ctx, models.UsernameAlias{
+		ID:        primitive.NewObjectID(),
+		Username:  "sam",
+		UserID:    holder,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.NoError(t, retryOrphanedMessages(ctx, db))
+
+	placed := stored(sentTo["sam"])
+	assert.Equal(t, holder, placed["ownerId"])
+	assert.EqualValues(t, 4, placed["schemaVersion"])
+	assert.Equal(t, stored(sentTo["quinn"])["promptId"], placed["promptId"], "filed under the owner's default prompt")
+	orphans, err = db.Collection("orphaned_messages").CountDocuments(ctx, bson.M{})
+	require.NoError(t, err)
+	assert.Zero(t, orphans)
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/Investorharry19/voxa-golang-server/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// messagePromptID files every existing message under its owner's default
+// prompt, creating that prompt where the owner has none yet. Unowned
+// messages are still at version 2; retryOrphanedMessages files them once
+// they have an owner.
+func messagePromptID(ctx context.Context, db *mongo.Database) error {
+	owners, err := db.Collection("messages").Distinct(ctx, "ownerId", bson.M{"schemaVersion": 3, "ownerId": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+
+	for _, value := range owners {
+		ownerID, ok := value.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		if err := fileUnderDefaultPrompt(ctx, db, ownerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileUnderDefaultPrompt moves ownerID's version 3 messages to their
+// default prompt.
+func fileUnderDefaultPrompt(ctx context.Context, db *mongo.Database, ownerID primitive.ObjectID) error {
+	prompt, err := utils.EnsureDefaultPrompt(ctx, db.Collection("prompts"), ownerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("messages").UpdateMany(
+		ctx,
+		bson.M{"schemaVersion": 3, "ownerId": ownerID},
+		bson.M{"$set": bson.M{"promptId": prompt.ID, "schemaVersion": 4}},
+	)
+	return err
+}
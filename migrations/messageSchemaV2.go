@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// messageSchemaV2 rewrites messages stored under the driver's default field
+// names (ownerusername, messagetext, audiourl, ...) into version 2 of the
+// message layout, which still keyed the owner by username. Documents saved
+// without a type are typed by whether they have audio.
+func messageSchemaV2(ctx context.Context, db *mongo.Database) error {
+	hasAudio := bson.M{"$gt": bson.A{bson.M{"$strLenCP": bson.M{"$ifNull": bson.A{"$audiourl", ""}}}, 0}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"type": bson.M{"$ifNull": bson.A{
+				"$type",
+				bson.M{"$cond": bson.A{hasAudio, models.MessageTypeAudio, models.MessageTypeText}},
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"schemaVersion": 2,
+			"ownerUsername": "$ownerusername",
+			"isOpened":      bson.M{"$ifNull": bson.A{"$isopened", false}},
+			"isStarred":     bson.M{"$ifNull": bson.A{"$isstarred", false}},
+			"createdAt":     bson.M{"$ifNull": bson.A{"$createdat", bson.M{"$toDate": "$_id"}}},
+			"text": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$type", models.MessageTypeText}},
+				bson.M{"body": bson.M{"$ifNull": bson.A{"$messagetext", ""}}},
+				"$$REMOVE",
+			}},
+			"audio": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$type", models.MessageTypeAudio}},
+				bson.M{
+					"url":      bson.M{"$ifNull": bson.A{"$audiourl", ""}},
+					"publicId": bson.M{"$ifNull": bson.A{"$publicid", ""}},
+				},
+				"$$REMOVE",
+			}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"ownerusername", "messagetext", "audiourl", "publicid", "isopened", "isstarred", "createdat"}}},
+	}
+
+	_, err := db.Collection("messages").UpdateMany(
+		ctx,
+		bson.M{"schemaVersion": bson.M{"$exists": false}},
+		pipeline,
+	)
+	return err
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration rewrites stored data once. Up must be safe to run again:
+// replicas starting together may both apply it before either records it.
+// Retry, when set, runs on every start after Up has been recorded, for data
+// Up had to leave behind; it must be cheap when there is nothing to do.
+type Migration struct {
+	ID    string
+	Up    func(ctx context.Context, db *mongo.Database) error
+	Retry func(ctx context.Context, db *mongo.Database) error
+}
+
+// all lists every migration in the order they are applied. Append only.
+var all = []Migration{
+	{ID: "0001_message_schema_v2", Up: messageSchemaV2},
+	{ID: "0002_message_owner_id", Up: messageOwnerID, Retry: retryOrphanedMessages},
+	{ID: "0003_message_prompt_id", Up: messagePromptID},
+	{ID: "0004_answer_message_deleted_at", Up: answerMessageDeletedAt},
+}
+
+// Run applies the migrations not yet recorded in schema_migrations. Call it
+// after database.ConnectMongoDB and before serving requests.
+func Run(ctx context.Context) error {
+	applied := database.GetCollection("schema_migrations")
+	for _, migration := range all {
+		err := applied.FindOne(ctx, bson.M{"_id": migration.ID}).Err()
+		if err == nil {
+			if migration.Retry != nil {
+				if err := migration.Retry(ctx, database.DB); err != nil {
+					return fmt.Errorf("migration %s: %w", migration.ID, err)
+				}
+			}
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		start := time.Now()
+		if err := migration.Up(ctx, database.DB); err != nil {
+			return fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+		_, err = applied.UpdateOne(
+			ctx,
+			bson.M{"_id": migration.ID},
+			bson.M{"$setOnInsert": bson.M{"appliedAt": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+		fmt.Printf("Applied migration %s in %s\n", migration.ID, time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
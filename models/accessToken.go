@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopes a personal access token can be granted.
+const (
+	ScopeMessagesRead  = "messages:read"
+	ScopeMessagesWrite = "messages:write"
+	ScopeAccountRead   = "account:read"
+)
+
+var AccessTokenScopes = []string{ScopeMessagesRead, ScopeMessagesWrite, ScopeAccountRead}
+
+// PersonalAccessToken is a long-lived credential for scripts and
+// integrations. Only the hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	UserID     primitive.ObjectID `bson:"userId"`
+	Name       string             `bson:"name"`
+	TokenHash  string             `bson:"tokenHash"`
+	Prefix     string             `bson:"prefix"` // start of the token, to tell them apart
+	Scopes     []string           `bson:"scopes"`
+	CreatedAt  time.Time          `bson:"createdAt"`
+	LastUsedAt *time.Time         `bson:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time         `bson:"expiresAt,omitempty"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty"`
+}
+
+type CreateAccessTokenRequestDTO struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays is optional; zero means the token never expires
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+type AccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+type CreatedAccessTokenResponse struct {
+	AccessTokenResponse
+	// Token is shown once, at creation
+	Token string `json:"token"`
+}
+
+func AccessTokenToAccessTokenResponse(token *PersonalAccessToken) AccessTokenResponse {
+	return AccessTokenResponse{
+		ID:         token.ID.Hex(),
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsedAt,
+		ExpiresAt:  token.ExpiresAt,
+	}
+}
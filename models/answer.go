@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Answer is the owner's reply to one of their messages. It shows on their
+// public answers feed while PublishedAt is set.
+type Answer struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id"`
+	MessageID        primitive.ObjectID `json:"messageId" bson:"messageId"`
+	OwnerID          primitive.ObjectID `json:"ownerId" bson:"ownerId"`
+	Type             string             `json:"type" bson:"type"`
+	Text             *TextPayload       `json:"text,omitempty" bson:"text,omitempty"`
+	Audio            *AudioPayload      `json:"audio,omitempty" bson:"audio,omitempty"`
+	PublishedAt      *time.Time         `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	CreatedAt        time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt        time.Time          `json:"updatedAt" bson:"updatedAt"`
+	MessageDeletedAt *time.Time         `json:"-" bson:"messageDeletedAt,omitempty"` // set while the message is in the trash
+}
+
+type TextAnswerRequestDTO struct {
+	Text string `json:"text"`
+	// Publish also publishes the answer; it never unpublishes one
+	Publish bool `json:"publish"`
+}
+
+// PublicContent is a message or answer as shown on the public feed.
+type PublicContent struct {
+	Type     string       `json:"type"`
+	Text     *TextPayload `json:"text,omitempty"`
+	AudioURL string       `json:"audioUrl,omitempty"`
+}
+
+type PublicAnswerResponse struct {
+	ID          string        `json:"id"`
+	Question    PublicContent `json:"question"`
+	AskedAt     time.Time     `json:"askedAt"`
+	Answer      PublicContent `json:"answer"`
+	PublishedAt time.Time     `json:"publishedAt"`
+}
+
+type AnswerFeedResponse struct {
+	Answers    []PublicAnswerResponse `json:"answers"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+func publicContent(contentType string, text *TextPayload, audio *AudioPayload) PublicContent {
+	content := PublicContent{Type: contentType, Text: text}
+	if audio != nil {
+		content.AudioURL = audio.URL
+	}
+	return content
+}
+
+func AnswerToPublicAnswerResponse(answer *Answer, message *Message) PublicAnswerResponse {
+	response := PublicAnswerResponse{
+		ID:       answer.ID.Hex(),
+		Question: publicContent(message.Type, message.Text, message.Audio),
+		AskedAt:  message.CreatedAt,
+		Answer:   publicContent(answer.Type, answer.Text, answer.Audio),
+	}
+	if answer.PublishedAt != nil {
+		response.PublishedAt = *answer.PublishedAt
+	}
+	return response
+}
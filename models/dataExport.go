@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+)
+
+// DataExport is one request for a copy of a user's data. The worker builds
+// the archive and stores it as a private Cloudinary asset until ExpiresAt.
+type DataExport struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	UserID      primitive.ObjectID `bson:"userId"`
+	Status      string             `bson:"status"`
+	Attempts    int                `bson:"attempts"`
+	PublicID    string             `bson:"publicId,omitempty"`
+	Error       string             `bson:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+	LeaseUntil  *time.Time         `bson:"leaseUntil,omitempty"`
+	NotBefore   *time.Time         `bson:"notBefore,omitempty"` // a failed attempt waits until then to retry
+	CompletedAt *time.Time         `bson:"completedAt,omitempty"`
+	ExpiresAt   *time.Time         `bson:"expiresAt,omitempty"`
+}
+
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	// DownloadURL is signed and short-lived; fetch the export again for a
+	// fresh one
+	DownloadURL          string     `json:"downloadUrl,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"downloadUrlExpiresAt,omitempty"`
+}
+
+func DataExportToDataExportResponse(export *DataExport) DataExportResponse {
+	return DataExportResponse{
+		ID:          export.ID.Hex(),
+		Status:      export.Status,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
+
+// ExportProfile is the part of User that goes into an export. Credentials
+// and second-factor secrets are left out.
+type ExportProfile struct {
+	ID                      string     `json:"id"`
+	Username                string     `json:"username"`
+	Email                   string     `json:"email,omitempty"`
+	EmailVerified           bool       `json:"emailVerified"`
+	MFAEnabled              bool       `json:"mfaEnabled"`
+	PushNotificationEnabled bool       `json:"pushNotificationEnabled"`
+	PushToken               []string   `json:"pushToken"`
+	UsernameChangedAt       *time.Time `json:"usernameChangedAt,omitempty"`
+	DisplayName             string     `json:"displayName,omitempty"`
+	Bio                     string     `json:"bio,omitempty"`
+	InboxPrompt             string     `json:"inboxPrompt,omitempty"`
+	AvatarURL               string     `json:"avatarUrl,omitempty"`
+}
+
+func UserToExportProfile(user *User) ExportProfile {
+	return ExportProfile{
+		ID:                      user.ID.Hex(),
+		Username:                user.Username,
+		Email:                   user.Email,
+		EmailVerified:           user.EmailVerified,
+		MFAEnabled:              user.MFAEnabled,
+		PushNotificationEnabled: user.PushNotificationEnabled,
+		PushToken:               user.PushToken,
+		UsernameChangedAt:       user.UsernameChangedAt,
+		DisplayName:             user.DisplayName,
+		Bio:                     user.Bio,
+		InboxPrompt:             user.InboxPrompt,
+		AvatarURL:               user.AvatarURL,
+	}
+}
+
+type ExportMessage struct {
+	Message
+	Answer  *Answer        `json:"answer,omitempty"`
+	Replies []MessageReply `json:"replies,omitempty"`
+	// AudioFile is the archive path of the downloaded audio
+	AudioFile  string `json:"audioFile,omitempty"`
+	AudioError string `json:"audioError,omitempty"`
+}
+
+// ExportManifest is manifest.json at the root of the archive.
+type ExportManifest struct {
+	ExportedAt time.Time       `json:"exportedAt"`
+	Profile    ExportProfile   `json:"profile"`
+	Prompts    []Prompt        `json:"prompts"`
+	Messages   []ExportMessage `json:"messages"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MediaDeletion is a Cloudinary asset waiting to be destroyed. Rows are
+// written alongside the data that referenced the asset and removed once
+// Cloudinary confirms, so a failed call is retried later.
+type MediaDeletion struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	PublicID      string             `bson:"publicId"`
+	ResourceType  string             `bson:"resourceType"`
+	DeliveryType  string             `bson:"deliveryType,omitempty"` // "upload" when empty
+	Attempts      int                `bson:"attempts"`
+	LastError     string             `bson:"lastError,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt"`
+}
@@ -6,49 +6,123 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Message types. Type says which of the payloads a message carries.
+const (
+	MessageTypeText  = "text"
+	MessageTypeAudio = "audio"
+)
+
+// MessageSchemaVersion is written on every message. Migrations bring older
+// documents up to it.
+const MessageSchemaVersion = 4
+
+// Message is a message in someone's inbox, as stored and as returned by
+// the API. The owner is referenced by ID so renames and reused usernames
+// never move an inbox. Every message is filed under one of the owner's
+// prompts.
 type Message struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id"`
-	OwnerUsername string             `json:"ownerUsername"`
-	MessageText   string             `json:"messageText,omitempty"`
-	AudioUrl      string             `json:"audioUrl,omitempty"`
-	IsOpened      bool               `json:"isOpened"`
-	PublicId      string             `json:"publicId,omitempty"`
-	IsStarred     bool               `json:"isStarred"`
-	CreatedAt     time.Time          `json:"createdAt"`
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	SchemaVersion   int                `json:"schemaVersion" bson:"schemaVersion"`
+	Type            string             `json:"type" bson:"type"`
+	OwnerID         primitive.ObjectID `json:"ownerId" bson:"ownerId"`
+	PromptID        primitive.ObjectID `json:"promptId" bson:"promptId"`
+	Text            *TextPayload       `json:"text,omitempty" bson:"text,omitempty"`
+	Audio           *AudioPayload      `json:"audio,omitempty" bson:"audio,omitempty"`
+	IsOpened        bool               `json:"isOpened" bson:"isOpened"`
+	IsStarred       bool               `json:"isStarred" bson:"isStarred"`
+	CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
+	SenderTokenHash string             `json:"-" bson:"senderTokenHash,omitempty"` // lets the sender follow the thread
+	ReplyCount      int                `json:"replyCount" bson:"replyCount"`
+	LastReplyAt     *time.Time         `json:"lastReplyAt,omitempty" bson:"lastReplyAt,omitempty"`
+	DeletedAt       *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"` // set while in the trash
 }
 
-type TextMessageRequestDTO struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id"`
-	OwnerUsername string             `json:"ownerUsername"`
-	MessageText   string             `json:"messageText,omitempty"`
-	Type          string             `json:"type"`
-	IsOpened      bool               `json:"isOpened"`
-	CreatedAt     time.Time          `json:"createdAt"`
-	IsStarred     bool               `json:"isStarred"`
-}
-type AudioMessageRequestDTO struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id"`
-	OwnerUsername string             `json:"ownerUsername"`
-	Type          string             `json:"type"`
-	IsOpened      bool               `json:"isOpened"`
-	CreatedAt     time.Time          `json:"createdAt"`
-	IsStarred     bool               `json:"isStarred"`
-	AudioUrl      string             `json:"audioUrl,omitempty"`
-	PublicId      string             `json:"publicId,omitempty"`
+type TextPayload struct {
+	Body string `json:"body" bson:"body"`
 }
 
-type MessageMarkAsRead struct {
-	State *bool `json:"isStarred"`
+// AudioPayload points at the voice-filtered recording on Cloudinary.
+type AudioPayload struct {
+	URL      string `json:"url" bson:"url"`
+	PublicID string `json:"publicId" bson:"publicId"`
+}
+
+func NewTextMessage(ownerID, promptID primitive.ObjectID, body string) Message {
+	return Message{
+		ID:            primitive.NewObjectID(),
+		SchemaVersion: MessageSchemaVersion,
+		Type:          MessageTypeText,
+		OwnerID:       ownerID,
+		PromptID:      promptID,
+		Text:          &TextPayload{Body: body},
+		CreatedAt:     time.Now(),
+	}
+}
+
+func NewAudioMessage(ownerID, promptID primitive.ObjectID, url, publicID string) Message {
+	return Message{
+		ID:            primitive.NewObjectID(),
+		SchemaVersion: MessageSchemaVersion,
+		Type:          MessageTypeAudio,
+		OwnerID:       ownerID,
+		PromptID:      promptID,
+		Audio:         &AudioPayload{URL: url, PublicID: publicID},
+		CreatedAt:     time.Now(),
+	}
+}
+
+// MessagePageResponse is one page of an inbox listing. NextCursor is empty
+// on the last page.
+type MessagePageResponse struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"nextCursor,omitempty"`
 }
-type TextMessageRequestSwagger struct {
+
+type TextMessageRequestDTO struct {
 	OwnerUsername string `json:"ownerUsername"`
-	MessageText   string `json:"messageText,omitempty"`
+	// PromptID files the message under one of the owner's prompts; empty
+	// means their default prompt
+	PromptID    string `json:"promptId"`
+	MessageText string `json:"messageText"`
 }
 
-/*
+type MessageMarkAsRead struct {
+	State *bool `json:"isStarred"`
+}
 
+// Bulk actions on messages.
+const (
+	BulkActionRead   = "read"
+	BulkActionUnread = "unread"
+	BulkActionStar   = "star"
+	BulkActionUnstar = "unstar"
+	BulkActionTrash  = "trash"
+	BulkActionDelete = "delete"
+)
 
+// Outcomes of a bulk action for one message ID.
+const (
+	BulkResultOK        = "ok"
+	BulkResultNotFound  = "not_found"
+	BulkResultInvalidID = "invalid_id"
+)
 
+// BulkMessageRequestDTO applies Action to the messages in IDs or, when IDs
+// is empty, to the messages matching the inbox filters in the query string.
+type BulkMessageRequestDTO struct {
+	Action string   `json:"action"`
+	IDs    []string `json:"ids"`
+}
 
+type BulkMessageResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
 
- */
+// BulkMessageResponse reports the outcome for each message. HasMore means a
+// filter matched more messages than one request handles; repeat it to
+// continue.
+type BulkMessageResponse struct {
+	Results []BulkMessageResult `json:"results"`
+	HasMore bool                `json:"hasMore"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OAuthState carries an in-flight OIDC login between the redirect to the
+// provider and the callback. It is deleted when the callback consumes it.
+type OAuthState struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	StateHash    string             `bson:"stateHash"`
+	Provider     string             `bson:"provider"`
+	Nonce        string             `bson:"nonce"`
+	CodeVerifier string             `bson:"codeVerifier"`
+	CreatedAt    time.Time          `bson:"createdAt"`
+	ExpiresAt    time.Time          `bson:"expiresAt"`
+}
+
+// UserIdentity links an external OIDC subject to a Voxa user.
+type UserIdentity struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	Provider  string             `bson:"provider"`
+	Subject   string             `bson:"subject"`
+	Email     string             `bson:"email,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
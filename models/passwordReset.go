@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordReset is a single-use reset token. Only its SHA-256 is stored.
+type PasswordReset struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	TokenHash string             `bson:"tokenHash"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+	UsedAt    *time.Time         `bson:"usedAt,omitempty"`
+}
+
+type ChangePasswordRequestDTO struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type PasswordResetRequestDTO struct {
+	// Username or email address of the account
+	Username string `json:"username"`
+}
+
+type PasswordResetConfirmDTO struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
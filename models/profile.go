@@ -0,0 +1,29 @@
+package models
+
+// PublicProfileResponse is what anyone holding a share link may see about
+// the recipient.
+type PublicProfileResponse struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+	InboxPrompt string `json:"inboxPrompt,omitempty"`
+	AvatarURL   string `json:"avatarUrl,omitempty"`
+}
+
+func UserToPublicProfileResponse(user *User) PublicProfileResponse {
+	return PublicProfileResponse{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		InboxPrompt: user.InboxPrompt,
+		AvatarURL:   user.AvatarURL,
+	}
+}
+
+// UpdateProfileRequestDTO changes only the fields that are present; an empty
+// string clears one.
+type UpdateProfileRequestDTO struct {
+	DisplayName *string `json:"displayName"`
+	Bio         *string `json:"bio"`
+	InboxPrompt *string `json:"inboxPrompt"`
+}
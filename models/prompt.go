@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultPromptTitle is the title of the prompt every inbox starts with.
+// Messages sent without a prompt land there.
+const DefaultPromptTitle = "Send me an anonymous message"
+
+// Prompt is one question ("box") an inbox owner collects messages under.
+// Each has its own share link by Slug. Archived prompts stop taking
+// messages but keep the ones they have.
+type Prompt struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	UserID     primitive.ObjectID `json:"-" bson:"userId"`
+	Title      string             `json:"title" bson:"title"`
+	Slug       string             `json:"slug" bson:"slug"`
+	Position   int                `json:"position" bson:"position"`
+	IsDefault  bool               `json:"isDefault" bson:"isDefault"`
+	ArchivedAt *time.Time         `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type CreatePromptRequestDTO struct {
+	Title string `json:"title"`
+}
+
+// UpdatePromptRequestDTO changes only the fields that are present.
+type UpdatePromptRequestDTO struct {
+	Title    *string `json:"title"`
+	Archived *bool   `json:"archived"`
+}
+
+// ReorderPromptsRequestDTO lists every active prompt ID in the new order.
+type ReorderPromptsRequestDTO struct {
+	PromptIDs []string `json:"promptIds"`
+}
+
+// PublicPromptResponse is what a prompt's share link shows: the question
+// and whose inbox it goes to. Senders pass ID as promptId.
+type PublicPromptResponse struct {
+	ID      primitive.ObjectID    `json:"id"`
+	Title   string                `json:"title"`
+	Slug    string                `json:"slug"`
+	Profile PublicProfileResponse `json:"profile"`
+}
+
+func PromptToPublicPromptResponse(prompt *Prompt, user *User) PublicPromptResponse {
+	return PublicPromptResponse{
+		ID:      prompt.ID,
+		Title:   prompt.Title,
+		Slug:    prompt.Slug,
+		Profile: UserToPublicProfileResponse(user),
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is the stored form of an opaque refresh token. Only the
+// SHA-256 of the token is persisted; every token issued from the same login
+// shares a FamilyID so a replayed token can revoke the whole chain.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	FamilyID  primitive.ObjectID `bson:"familyId"`
+	TokenHash string             `bson:"tokenHash"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+	RotatedAt *time.Time         `bson:"rotatedAt,omitempty"`
+	RevokedAt *time.Time         `bson:"revokedAt,omitempty"`
+}
+
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type TokenPairResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Security event types shown to the account owner.
+const (
+	SecurityEventLoginFailed    = "login_failed"
+	SecurityEventLoginLocked    = "login_locked"
+	SecurityEventRoleChanged    = "role_changed"
+	SecurityEventPasskeyAdded   = "passkey_added"
+	SecurityEventPasskeyRemoved = "passkey_removed"
+)
+
+// SecurityEvent records something the account owner should know about, such
+// as someone guessing their password.
+type SecurityEvent struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	Type      string             `bson:"type"`
+	IP        string             `bson:"ip"`
+	UserAgent string             `bson:"userAgent"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+type SecurityEventResponse struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func SecurityEventToSecurityEventResponse(event *SecurityEvent) SecurityEventResponse {
+	return SecurityEventResponse{
+		ID:        event.ID.Hex(),
+		Type:      event.Type,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt,
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one signed-in device. Its ID is carried in the access token's
+// "sid" claim and doubles as the refresh-token family for that login.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	UserID     primitive.ObjectID `bson:"userId"`
+	IP         string             `bson:"ip"`
+	UserAgent  string             `bson:"userAgent"`
+	CreatedAt  time.Time          `bson:"createdAt"`
+	LastSeenAt time.Time          `bson:"lastSeenAt"`
+	ExpiresAt  time.Time          `bson:"expiresAt"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+func SessionToSessionResponse(session *Session, currentSessionID string) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID.Hex(),
+		IP:         session.IP,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Current:    session.ID.Hex() == currentSessionID,
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Who wrote a reply in a message thread.
+const (
+	ReplyAuthorOwner  = "owner"
+	ReplyAuthorSender = "sender"
+)
+
+// MessageReply is a private follow-up under a message, written either by
+// the inbox owner or by the anonymous sender holding the sender token.
+type MessageReply struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	MessageID primitive.ObjectID `json:"messageId" bson:"messageId"`
+	OwnerID   primitive.ObjectID `json:"-" bson:"ownerId"`
+	Author    string             `json:"author" bson:"author"`
+	Body      string             `json:"body" bson:"body"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type ThreadReplyRequestDTO struct {
+	Text string `json:"text"`
+}
+
+// ThreadResponse is the owner's view of a message and its replies.
+type ThreadResponse struct {
+	Message Message        `json:"message"`
+	Replies []MessageReply `json:"replies"`
+}
+
+// SenderThreadResponse is what the sender token unlocks: their own message
+// and the replies under it, without the owner's inbox state.
+type SenderThreadResponse struct {
+	Message PublicContent  `json:"message"`
+	SentAt  time.Time      `json:"sentAt"`
+	Replies []MessageReply `json:"replies"`
+}
+
+func MessageToSenderThreadResponse(message *Message, replies []MessageReply) SenderThreadResponse {
+	return SenderThreadResponse{
+		Message: publicContent(message.Type, message.Text, message.Audio),
+		SentAt:  message.CreatedAt,
+		Replies: replies,
+	}
+}
@@ -1,24 +1,94 @@
 package models
 
 import (
+	"slices"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserRequestDTO struct {
+	// Username also accepts the account's email address when logging in
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+}
+
+type UpdateEmailRequestDTO struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type DeleteAccountRequestDTO struct {
+	Password string `json:"password"`
+}
+
+type UpdateUsernameRequestDTO struct {
+	Username string `json:"username"`
+}
+
+// Roles, from least to most privileged. Each role can do everything the
+// ones before it can.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// RoleAtLeast reports whether role grants everything min does.
+func RoleAtLeast(role, min string) bool {
+	minRank := slices.Index(Roles, min)
+	return minRank >= 0 && slices.Index(Roles, role) >= minRank
+}
+
+type SetRoleRequestDTO struct {
+	Role string `json:"role"`
 }
 
 type User struct {
 	ID                      primitive.ObjectID `bson:"_id"`
 	Username                string             `bson:"username"`
 	Password                string             `bson:"password_hash"`
+	Email                   string             `bson:"email,omitempty"`
+	EmailVerified           bool               `bson:"emailVerified"`
 	PushNotificationEnabled bool               `bson:"pushNotificationEnabled"`
 	PushToken               []string           `bson:"pushToken"`
+	TokenVersion            int                `bson:"tokenVersion"` // bumped to invalidate every access token
+	MFAEnabled              bool               `bson:"mfaEnabled"`
+	TOTPSecret              string             `bson:"totpSecret,omitempty"`
+	TOTPPendingSecret       string             `bson:"totpPendingSecret,omitempty"` // awaiting the confirm step
+	TOTPLastStep            int64              `bson:"totpLastStep,omitempty"`      // last accepted time step, blocks replays
+	RecoveryCodes           []string           `bson:"recoveryCodes,omitempty"`     // SHA-256 of unused codes
+	UsernameChangedAt       *time.Time         `bson:"usernameChangedAt,omitempty"`
+	Role                    string             `bson:"role,omitempty"` // empty for accounts that predate roles
+	DisplayName             string             `bson:"displayName,omitempty"`
+	Bio                     string             `bson:"bio,omitempty"`
+	InboxPrompt             string             `bson:"inboxPrompt,omitempty"`
+	AvatarURL               string             `bson:"avatarUrl,omitempty"`
+	AvatarPublicID          string             `bson:"avatarPublicId,omitempty"`
 }
+
+// EffectiveRole is the user's role, treating accounts without one as users.
+func (user *User) EffectiveRole() string {
+	if user.Role == "" {
+		return RoleUser
+	}
+	return user.Role
+}
+
 type UserResponse struct {
 	ID                      string   `json:"id"`
 	Username                string   `json:"username"`
+	Email                   string   `json:"email,omitempty"`
+	EmailVerified           bool     `json:"emailVerified"`
+	MFAEnabled              bool     `json:"mfaEnabled"`
+	Role                    string   `json:"role"`
+	DisplayName             string   `json:"displayName,omitempty"`
+	Bio                     string   `json:"bio,omitempty"`
+	InboxPrompt             string   `json:"inboxPrompt,omitempty"`
+	AvatarURL               string   `json:"avatarUrl,omitempty"`
 	Token                   string   `json:"token"`
 	PushNotificationEnabled bool     `bson:"pushNotificationEnabled"`
 	PushToken               []string `bson:"pushToken"`
@@ -28,6 +98,14 @@ func UserToUserResponse(user *User, token string) UserResponse {
 	return UserResponse{
 		ID:                      user.ID.Hex(),
 		Username:                user.Username,
+		Email:                   user.Email,
+		EmailVerified:           user.EmailVerified,
+		MFAEnabled:              user.MFAEnabled,
+		Role:                    user.EffectiveRole(),
+		DisplayName:             user.DisplayName,
+		Bio:                     user.Bio,
+		InboxPrompt:             user.InboxPrompt,
+		AvatarURL:               user.AvatarURL,
 		Token:                   token,
 		PushNotificationEnabled: user.PushNotificationEnabled,
 		PushToken:               user.PushToken,
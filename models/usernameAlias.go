@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UsernameAlias keeps a previous username pointing at its account for a
+// while after a rename, so shared links keep reaching the same inbox.
+type UsernameAlias struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Username  string             `bson:"username"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+type UsernameChangeResponse struct {
+	Username         string     `json:"username"`
+	PreviousUsername string     `json:"previousUsername"`
+	AliasExpiresAt   *time.Time `json:"aliasExpiresAt,omitempty"`
+}
+
+type ResolvedUsernameResponse struct {
+	// Username is the current name; it differs from the one asked for when
+	// that was an alias or cased differently
+	Username string `json:"username"`
+	IsAlias  bool   `json:"isAlias"`
+}
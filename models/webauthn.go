@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebAuthn ceremony kinds a challenge can be spent on.
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthnCredential is a passkey registered to a user. CredentialID is kept
+// next to the library's record so it can be indexed.
+type WebAuthnCredential struct {
+	ID           primitive.ObjectID  `bson:"_id"`
+	UserID       primitive.ObjectID  `bson:"userId"`
+	Name         string              `bson:"name"`
+	CredentialID []byte              `bson:"credentialId"`
+	Credential   webauthn.Credential `bson:"credential"`
+	CreatedAt    time.Time           `bson:"createdAt"`
+	LastUsedAt   *time.Time          `bson:"lastUsedAt,omitempty"`
+}
+
+// WebAuthnChallenge holds the session of a ceremony between its begin and
+// finish calls. It is found by the challenge the browser echoes back and is
+// deleted once used.
+type WebAuthnChallenge struct {
+	ID        primitive.ObjectID   `bson:"_id"`
+	Challenge string               `bson:"challenge"`
+	Ceremony  string               `bson:"ceremony"`
+	UserID    *primitive.ObjectID  `bson:"userId,omitempty"` // registration only
+	Name      string               `bson:"name,omitempty"`   // name for the new passkey
+	Session   webauthn.SessionData `bson:"session"`
+	CreatedAt time.Time            `bson:"createdAt"`
+	ExpiresAt time.Time            `bson:"expiresAt"`
+}
+
+// WebAuthnUser adapts a User and their passkeys to webauthn.User. The user
+// handle given to authenticators is the raw ObjectID.
+type WebAuthnUser struct {
+	User        *User
+	Credentials []webauthn.Credential
+}
+
+func (u *WebAuthnUser) WebAuthnID() []byte {
+	return u.User.ID[:]
+}
+
+func (u *WebAuthnUser) WebAuthnName() string {
+	return u.User.Username
+}
+
+func (u *WebAuthnUser) WebAuthnDisplayName() string {
+	return u.User.Username
+}
+
+func (u *WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.Credentials
+}
+
+type BeginPasskeyRegistrationRequestDTO struct {
+	Name string `json:"name"`
+}
+
+type PasskeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+func WebAuthnCredentialToPasskeyResponse(credential *WebAuthnCredential) PasskeyResponse {
+	return PasskeyResponse{
+		ID:         credential.ID.Hex(),
+		Name:       credential.Name,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
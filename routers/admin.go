@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/Investorharry19/voxa-golang-server/controllers"
+	"github.com/Investorharry19/voxa-golang-server/middlewares"
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminRouter holds moderation and support tooling. Everything here needs a
+// signed-in session; each route then states the least role it needs.
+func AdminRouter(app *fiber.App) {
+	adminGroup := app.Group("/admin", middlewares.RequireAuth, middlewares.RequireSession)
+
+	adminGroup.Put("/users/:id/role", middlewares.RequireRole(models.RoleAdmin), controllers.SetUserRole)
+}
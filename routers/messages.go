@@ -3,6 +3,7 @@ package routers
 import (
 	"github.com/Investorharry19/voxa-golang-server/controllers"
 	"github.com/Investorharry19/voxa-golang-server/middlewares"
+	"github.com/Investorharry19/voxa-golang-server/models"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -11,12 +12,33 @@ func MessageRouter(app *fiber.App) {
 
 	messageGroup.Post("/send/text-message", controllers.AddTextMessage)
 	messageGroup.Post("/send/audio-message", controllers.SendAudioMessage)
+	messageGroup.Get("/sender/thread", controllers.GetSenderThread)
+	messageGroup.Post("/sender/thread/replies", controllers.ReplyAsSender)
 
-	messageGroup.Get("/get-messages", middlewares.RequireAuth, controllers.GetAllMessages)
-	messageGroup.Patch("/mark-as-read/:id", middlewares.RequireAuth, controllers.MarkAsRead)
-	messageGroup.Patch("/star-message/:id", middlewares.RequireAuth, controllers.StarMessage)
-	messageGroup.Delete("/delete-message/:id", middlewares.RequireAuth, controllers.DeleteOneMessage)
-	messageGroup.Delete("/delete-all-messages", middlewares.RequireAuth, controllers.DeleteAllMessages)
+	messagesRead := middlewares.RequireScope(models.ScopeMessagesRead)
+	messagesWrite := middlewares.RequireScope(models.ScopeMessagesWrite)
+
+	messageGroup.Get("/get-messages", middlewares.RequireAuth, messagesRead, controllers.GetAllMessages)
+	messageGroup.Patch("/mark-as-read/:id", middlewares.RequireAuth, messagesWrite, controllers.MarkAsRead)
+	messageGroup.Patch("/star-message/:id", middlewares.RequireAuth, messagesWrite, controllers.StarMessage)
+	messageGroup.Delete("/delete-message/:id", middlewares.RequireAuth, messagesWrite, controllers.DeleteOneMessage)
+	messageGroup.Delete("/delete-all-messages", middlewares.RequireAuth, messagesWrite, controllers.DeleteAllMessages)
+	messageGroup.Post("/bulk", middlewares.RequireAuth, messagesWrite, controllers.BulkMessages)
+
+	messageGroup.Get("/trash", middlewares.RequireAuth, messagesRead, controllers.GetTrash)
+	messageGroup.Post("/trash/:id/restore", middlewares.RequireAuth, messagesWrite, controllers.RestoreMessage)
+	messageGroup.Delete("/trash/:id", middlewares.RequireAuth, messagesWrite, controllers.DeleteTrashedMessage)
+	messageGroup.Delete("/trash", middlewares.RequireAuth, messagesWrite, controllers.EmptyTrash)
+
+	messageGroup.Get("/:id/answer", middlewares.RequireAuth, messagesRead, controllers.GetAnswer)
+	messageGroup.Put("/:id/answer/text", middlewares.RequireAuth, messagesWrite, controllers.AnswerWithText)
+	messageGroup.Put("/:id/answer/audio", middlewares.RequireAuth, messagesWrite, controllers.AnswerWithAudio)
+	messageGroup.Post("/:id/answer/publish", middlewares.RequireAuth, messagesWrite, controllers.PublishAnswer)
+	messageGroup.Post("/:id/answer/unpublish", middlewares.RequireAuth, messagesWrite, controllers.UnpublishAnswer)
+	messageGroup.Delete("/:id/answer", middlewares.RequireAuth, messagesWrite, controllers.DeleteAnswer)
+
+	messageGroup.Get("/:id/thread", middlewares.RequireAuth, messagesRead, controllers.GetThread)
+	messageGroup.Post("/:id/thread/replies", middlewares.RequireAuth, messagesWrite, controllers.ReplyToThread)
 
 	app.Get("/convert", controllers.HandleVideoBuffer)
 	app.Post("/process", controllers.ProcessAudioMessage)
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/Investorharry19/voxa-golang-server/controllers"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProfileRouter serves the public pages behind share links. Nothing here
+// needs authentication.
+func ProfileRouter(app *fiber.App) {
+	profileGroup := app.Group("/u")
+
+	profileGroup.Get("/:username", controllers.GetPublicProfile)
+	profileGroup.Get("/:username/answers", controllers.GetPublicAnswers)
+
+	app.Get("/p/:slug", controllers.GetPublicPrompt)
+}
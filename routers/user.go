@@ -3,14 +3,60 @@ package routers
 import (
 	"github.com/Investorharry19/voxa-golang-server/controllers"
 	"github.com/Investorharry19/voxa-golang-server/middlewares"
+	"github.com/Investorharry19/voxa-golang-server/models"
 	"github.com/gofiber/fiber/v2"
 )
 
 func UserRouter(app *fiber.App) {
 	accountGroup := app.Group("/account")
 
+	// Routes behind RequireAuth also say what personal access tokens may
+	// do there: RequireScope lets matching tokens in, RequireSession keeps
+	// them out entirely
+	accountRead := middlewares.RequireScope(models.ScopeAccountRead)
+
 	accountGroup.Post("/register", controllers.RegisterUser)
 	accountGroup.Post("/login", controllers.LoginUser)
-	accountGroup.Get("/current-user", middlewares.RequireAuth, controllers.GetCurrentUser)
+	accountGroup.Post("/login/mfa", controllers.LoginMFA)
+	accountGroup.Post("/login/passkey/begin", controllers.BeginPasskeyLogin)
+	accountGroup.Post("/login/passkey/finish", controllers.FinishPasskeyLogin)
+	accountGroup.Post("/refresh", controllers.RefreshToken)
+	accountGroup.Get("/current-user", middlewares.RequireAuth, accountRead, controllers.GetCurrentUser)
+	accountGroup.Post("/logout", middlewares.RequireAuth, middlewares.RequireSession, controllers.Logout)
+	accountGroup.Post("/logout-all", middlewares.RequireAuth, middlewares.RequireSession, controllers.LogoutAll)
+	accountGroup.Get("/sessions", middlewares.RequireAuth, accountRead, controllers.GetSessions)
+	accountGroup.Get("/security-events", middlewares.RequireAuth, accountRead, controllers.GetSecurityEvents)
+	accountGroup.Post("/change-password", middlewares.RequireAuth, middlewares.RequireSession, controllers.ChangePassword)
+	accountGroup.Post("/password-reset/request", controllers.RequestPasswordReset)
+	accountGroup.Post("/password-reset/confirm", controllers.ConfirmPasswordReset)
+	accountGroup.Put("/email", middlewares.RequireAuth, middlewares.RequireSession, controllers.UpdateEmail)
+	accountGroup.Post("/email/resend-verification", middlewares.RequireAuth, middlewares.RequireSession, controllers.ResendEmailVerification)
+	accountGroup.Get("/verify-email", controllers.VerifyEmail)
+	accountGroup.Put("/username", middlewares.RequireAuth, middlewares.RequireSession, controllers.ChangeUsername)
+	accountGroup.Get("/username/:username", controllers.ResolveUsername)
+	accountGroup.Post("/mfa/totp/enroll", middlewares.RequireAuth, middlewares.RequireSession, controllers.EnrollTOTP)
+	accountGroup.Post("/mfa/totp/confirm", middlewares.RequireAuth, middlewares.RequireSession, controllers.ConfirmTOTP)
+	accountGroup.Post("/mfa/totp/disable", middlewares.RequireAuth, middlewares.RequireSession, controllers.DisableTOTP)
+	accountGroup.Patch("/profile", middlewares.RequireAuth, middlewares.RequireSession, controllers.UpdateProfile)
+	accountGroup.Put("/profile/avatar", middlewares.RequireAuth, middlewares.RequireSession, controllers.UploadAvatar)
+	accountGroup.Delete("/profile/avatar", middlewares.RequireAuth, middlewares.RequireSession, controllers.DeleteAvatar)
+	accountGroup.Get("/prompts", middlewares.RequireAuth, middlewares.RequireSession, controllers.GetPrompts)
+	accountGroup.Post("/prompts", middlewares.RequireAuth, middlewares.RequireSession, controllers.CreatePrompt)
+	accountGroup.Put("/prompts/order", middlewares.RequireAuth, middlewares.RequireSession, controllers.ReorderPrompts)
+	accountGroup.Patch("/prompts/:id", middlewares.RequireAuth, middlewares.RequireSession, controllers.UpdatePrompt)
+	accountGroup.Post("/passkeys/register/begin", middlewares.RequireAuth, middlewares.RequireSession, controllers.BeginPasskeyRegistration)
+	accountGroup.Post("/passkeys/register/finish", middlewares.RequireAuth, middlewares.RequireSession, controllers.FinishPasskeyRegistration)
+	accountGroup.Get("/passkeys", middlewares.RequireAuth, accountRead, controllers.GetPasskeys)
+	accountGroup.Delete("/passkeys/:id", middlewares.RequireAuth, middlewares.RequireSession, controllers.DeletePasskey)
+	accountGroup.Get("/oauth/providers", controllers.GetOAuthProviders)
+	accountGroup.Get("/oauth/:provider", controllers.StartOAuthLogin)
+	accountGroup.Get("/oauth/:provider/callback", controllers.OAuthCallback)
+	accountGroup.Post("/oauth/:provider/callback", controllers.OAuthCallback)
+	accountGroup.Delete("/", middlewares.RequireAuth, middlewares.RequireSession, controllers.DeleteAccount)
+	accountGroup.Post("/exports", middlewares.RequireAuth, middlewares.RequireScope(models.ScopeAccountRead, models.ScopeMessagesRead), controllers.RequestDataExport)
+	accountGroup.Get("/exports/:id", middlewares.RequireAuth, middlewares.RequireScope(models.ScopeAccountRead, models.ScopeMessagesRead), controllers.GetDataExport)
+	accountGroup.Post("/tokens", middlewares.RequireAuth, middlewares.RequireSession, controllers.CreateAccessToken)
+	accountGroup.Get("/tokens", middlewares.RequireAuth, middlewares.RequireSession, controllers.GetAccessTokens)
+	accountGroup.Delete("/tokens/:id", middlewares.RequireAuth, middlewares.RequireSession, controllers.RevokeAccessToken)
 	accountGroup.Get("/users", controllers.GetUsers)
 }
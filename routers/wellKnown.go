@@ -0,0 +1,12 @@
+package routers
+
+import (
+	"github.com/Investorharry19/voxa-golang-server/controllers"
+	"github.com/gofiber/fiber/v2"
+)
+
+func WellKnownRouter(app *fiber.App) {
+	wellKnownGroup := app.Group("/.well-known")
+
+	wellKnownGroup.Get("/jwks.json", controllers.GetJWKS)
+}
@@ -6,7 +6,10 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/argon2"
 )
@@ -14,12 +17,114 @@ import (
 type Argon2Configuration struct {
 	HashRaw    []byte
 	Salt       []byte
+	Version    int
 	TimeCost   uint32
 	MemoryCost uint32
 	Threads    uint8
 	KeyLength  uint32
 }
 
+// ErrHasherBusy is returned when no Argon2 slot frees up within
+// argon2SlotTimeout; callers should answer 503 rather than queue forever.
+var ErrHasherBusy = errors.New("password hasher is busy")
+
+const (
+	argon2SaltLength  = 16
+	argon2SlotTimeout = 10 * time.Second
+)
+
+// argon2Params are the cost parameters for new hashes. InitArgon2 overrides
+// them from the environment.
+var argon2Params = Argon2Configuration{
+	TimeCost:   2,
+	MemoryCost: 64 * 1024,
+	Threads:    4,
+	KeyLength:  32,
+}
+
+// argon2Slots bounds how many hashes run at once, each of which allocates
+// MemoryCost KiB.
+var argon2Slots = make(chan struct{}, 4)
+
+// InitArgon2 loads and validates the hashing cost from ARGON2_TIME_COST,
+// ARGON2_MEMORY_KIB, ARGON2_THREADS and ARGON2_MAX_CONCURRENCY. Unset
+// variables keep their defaults.
+func InitArgon2() error {
+	params := argon2Params
+	concurrency := cap(argon2Slots)
+
+	if err := envUint("ARGON2_TIME_COST", 1, 20, func(v uint64) { params.TimeCost = uint32(v) }); err != nil {
+		return err
+	}
+	if err := envUint("ARGON2_MEMORY_KIB", 8*1024, 4*1024*1024, func(v uint64) { params.MemoryCost = uint32(v) }); err != nil {
+		return err
+	}
+	if err := envUint("ARGON2_THREADS", 1, 255, func(v uint64) { params.Threads = uint8(v) }); err != nil {
+		return err
+	}
+	if err := envUint("ARGON2_MAX_CONCURRENCY", 1, 1024, func(v uint64) { concurrency = int(v) }); err != nil {
+		return err
+	}
+	if params.MemoryCost < 8*uint32(params.Threads) {
+		return fmt.Errorf("ARGON2_MEMORY_KIB must be at least 8 KiB per thread")
+	}
+
+	argon2Params = params
+	argon2Slots = make(chan struct{}, concurrency)
+	return nil
+}
+
+func envUint(name string, min, max uint64, set func(uint64)) error {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return nil
+	}
+	value, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil || value < min || value > max {
+		return fmt.Errorf("%s must be an integer between %d and %d", name, min, max)
+	}
+	set(value)
+	return nil
+}
+
+// computeArgon2 runs argon2id once a concurrency slot is available. New
+// hashes leave Version unset and get the current version.
+func computeArgon2(password []byte, config *Argon2Configuration) ([]byte, error) {
+	slots := argon2Slots
+	select {
+	case slots <- struct{}{}:
+	default:
+		// time.After would leave a timer behind for every contended hash
+		timer := time.NewTimer(argon2SlotTimeout)
+		select {
+		case slots <- struct{}{}:
+			timer.Stop()
+		case <-timer.C:
+			return nil, ErrHasherBusy
+		}
+	}
+	defer func() { <-slots }()
+
+	if config.Version == argon2Version10 {
+		return argon2IDVersion10(
+			password,
+			config.Salt,
+			config.TimeCost,
+			config.MemoryCost,
+			config.Threads,
+			config.KeyLength,
+		), nil
+	}
+	return argon2.IDKey(
+		password,
+		config.Salt,
+		config.TimeCost,
+		config.MemoryCost,
+		config.Threads,
+		config.KeyLength,
+	), nil
+}
+
 func generateCryptographicSalt(saltSize uint32) ([]byte, error) {
 	salt := make([]byte, saltSize)
 	_, err := rand.Read(salt)
@@ -30,28 +135,19 @@ func generateCryptographicSalt(saltSize uint32) ([]byte, error) {
 }
 
 func HashPasswordSecure(password string) (string, error) {
-	config := &Argon2Configuration{
-		TimeCost:   2,
-		MemoryCost: 64 * 1024,
-		Threads:    4,
-		KeyLength:  32,
-	}
+	config := argon2Params
 
-	salt, err := generateCryptographicSalt(16)
+	salt, err := generateCryptographicSalt(argon2SaltLength)
 	if err != nil {
 		return "", fmt.Errorf("password hashing failed: %w", err)
 	}
 	config.Salt = salt
 
 	// Execute Argon2id hashing algorithm
-	config.HashRaw = argon2.IDKey(
-		[]byte(password),
-		config.Salt,
-		config.TimeCost,
-		config.MemoryCost,
-		config.Threads,
-		config.KeyLength,
-	)
+	config.HashRaw, err = computeArgon2([]byte(password), &config)
+	if err != nil {
+		return "", fmt.Errorf("password hashing failed: %w", err)
+	}
 
 	encodedHash := fmt.Sprintf(
 		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
@@ -73,18 +169,25 @@ func parseArgon2Hash(encodedHash string) (*Argon2Configuration, error) {
 	}
 
 	// Validate algorithm identifier
-	if !strings.HasPrefix(components[1], "argon2id") {
+	if components[1] != "argon2id" {
 		return nil, errors.New("unsupported algorithm variant")
 	}
 
+	config := &Argon2Configuration{}
+
 	// Extract version information
-	var version int
-	fmt.Sscanf(components[2], "v=%d", &version)
+	if _, err := fmt.Sscanf(components[2], "v=%d", &config.Version); err != nil {
+		return nil, fmt.Errorf("version parsing failed: %w", err)
+	}
 
 	// Parse configuration parameters
-	config := &Argon2Configuration{}
-	fmt.Sscanf(components[3], "m=%d,t=%d,p=%d",
-		&config.MemoryCost, &config.TimeCost, &config.Threads)
+	if _, err := fmt.Sscanf(components[3], "m=%d,t=%d,p=%d",
+		&config.MemoryCost, &config.TimeCost, &config.Threads); err != nil {
+		return nil, fmt.Errorf("parameter parsing failed: %w", err)
+	}
+	if config.MemoryCost == 0 || config.TimeCost == 0 || config.Threads == 0 {
+		return nil, errors.New("invalid hash parameters")
+	}
 
 	// Decode salt component
 	salt, err := base64.RawStdEncoding.DecodeString(components[4])
@@ -98,6 +201,9 @@ func parseArgon2Hash(encodedHash string) (*Argon2Configuration, error) {
 	if err != nil {
 		return nil, fmt.Errorf("hash decoding failed: %w", err)
 	}
+	if len(hash) == 0 {
+		return nil, errors.New("empty hash")
+	}
 	config.HashRaw = hash
 	config.KeyLength = uint32(len(hash))
 
@@ -105,23 +211,45 @@ func parseArgon2Hash(encodedHash string) (*Argon2Configuration, error) {
 }
 
 func VerifyPasswordSecure(storedHash, providedPassword string) (bool, error) {
+	// Accounts created through social login have no password at all
+	if storedHash == "" {
+		return false, nil
+	}
+
 	// Parse stored hash parameters
 	config, err := parseArgon2Hash(storedHash)
 	if err != nil {
 		return false, fmt.Errorf("hash parsing failed: %w", err)
 	}
 
+	// Version 0x10 hashes still verify; PasswordNeedsRehash upgrades them
+	if config.Version != argon2.Version && config.Version != argon2Version10 {
+		return false, fmt.Errorf("unsupported argon2 version %d", config.Version)
+	}
+
 	// Generate hash using identical parameters
-	computedHash := argon2.IDKey(
-		[]byte(providedPassword),
-		config.Salt,
-		config.TimeCost,
-		config.MemoryCost,
-		config.Threads,
-		config.KeyLength,
-	)
+	computedHash, err := computeArgon2([]byte(providedPassword), config)
+	if err != nil {
+		return false, err
+	}
 
 	// Perform constant-time comparison to prevent timing attacks
 	match := subtle.ConstantTimeCompare(config.HashRaw, computedHash) == 1
 	return match, nil
 }
+
+// PasswordNeedsRehash reports whether storedHash was made with an older
+// Argon2 version or with weaker parameters than the configured ones. Hashes
+// with stronger parameters are left alone so lowering the cost never
+// downgrades existing passwords.
+func PasswordNeedsRehash(storedHash string) bool {
+	config, err := parseArgon2Hash(storedHash)
+	if err != nil {
+		return false
+	}
+	return config.Version < argon2.Version ||
+		config.MemoryCost < argon2Params.MemoryCost ||
+		config.TimeCost < argon2Params.TimeCost ||
+		config.KeyLength < argon2Params.KeyLength ||
+		len(config.Salt) < argon2SaltLength
+}
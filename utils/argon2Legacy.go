@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// x/crypto/argon2 only computes the current Argon2 version (0x13). Hashes
+// written by libraries that still used version 0x10 are verified with this
+// port of the same algorithm instead, and upgraded on the next login.
+// Version 0x10 differs from 0x13 only in the version number hashed into H0
+// and in overwriting, rather than XORing into, blocks on later passes.
+
+const (
+	argon2Version10 = 0x10
+	argon2Version13 = 0x13
+
+	argon2ModeI  = 1
+	argon2ModeID = 2
+
+	argon2BlockLength = 128
+	argon2SyncPoints  = 4
+)
+
+type argon2Block [argon2BlockLength]uint64
+
+// argon2IDVersion10 computes an argon2id v1.0 key.
+func argon2IDVersion10(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	return argon2Derive(argon2ModeID, argon2Version10, password, salt, time, memory, threads, keyLen)
+}
+
+func argon2Derive(mode, version int, password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	h0 := argon2InitHash(password, salt, time, memory, uint32(threads), keyLen, mode, version)
+	memory = memory / (argon2SyncPoints * uint32(threads)) * (argon2SyncPoints * uint32(threads))
+	if memory < 2*argon2SyncPoints*uint32(threads) {
+		memory = 2 * argon2SyncPoints * uint32(threads)
+	}
+	blocks := argon2InitBlocks(&h0, memory, uint32(threads))
+	argon2ProcessBlocks(blocks, time, memory, uint32(threads), mode, version)
+	return argon2ExtractKey(blocks, memory, uint32(threads), keyLen)
+}
+
+func argon2InitHash(password, salt []byte, time, memory, threads, keyLen uint32, mode, version int) [blake2b.Size + 8]byte {
+	var (
+		h0     [blake2b.Size + 8]byte
+		params [24]byte
+		tmp    [4]byte
+	)
+	b2, _ := blake2b.New512(nil)
+	binary.LittleEndian.PutUint32(params[0:4], threads)
+	binary.LittleEndian.PutUint32(params[4:8], keyLen)
+	binary.LittleEndian.PutUint32(params[8:12], memory)
+	binary.LittleEndian.PutUint32(params[12:16], time)
+	binary.LittleEndian.PutUint32(params[16:20], uint32(version))
+	binary.LittleEndian.PutUint32(params[20:24], uint32(mode))
+	b2.Write(params[:])
+	// Password and salt, then an empty secret and empty associated data
+	for _, field := range [][]byte{password, salt, nil, nil} {
+		binary.LittleEndian.PutUint32(tmp[:], uint32(len(field)))
+		b2.Write(tmp[:])
+		b2.Write(field)
+	}
+	b2.Sum(h0[:0])
+	return h0
+}
+
+func argon2InitBlocks(h0 *[blake2b.Size + 8]byte, memory, threads uint32) []argon2Block {
+	var block0 [1024]byte
+	blocks := make([]argon2Block, memory)
+	for lane := uint32(0); lane < threads; lane++ {
+		j := lane * (memory / threads)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+		for i := uint32(0); i < 2; i++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], i)
+			argon2Blake2bHash(block0[:], h0[:])
+			for k := range blocks[j+i] {
+				blocks[j+i][k] = binary.LittleEndian.Uint64(block0[k*8:])
+			}
+		}
+	}
+	return blocks
+}
+
+func argon2ProcessBlocks(blocks []argon2Block, time, memory, threads uint32, mode, version int) {
+	lanes := memory / threads
+	segments := lanes / argon2SyncPoints
+
+	processSegment := func(n, slice, lane uint32, wg *sync.WaitGroup) {
+		defer wg.Done()
+		var addresses, in, zero argon2Block
+		dataIndependent := mode == argon2ModeI || (mode == argon2ModeID && n == 0 && slice < argon2SyncPoints/2)
+		if dataIndependent {
+			in[0] = uint64(n)
+			in[1] = uint64(lane)
+			in[2] = uint64(slice)
+			in[3] = uint64(memory)
+			in[4] = uint64(time)
+			in[5] = uint64(mode)
+		}
+
+		index := uint32(0)
+		if n == 0 && slice == 0 {
+			// The first two blocks of each lane come from H0
+			index = 2
+			if dataIndependent {
+				in[6]++
+				argon2ProcessBlock(&addresses, &in, &zero, false)
+				argon2ProcessBlock(&addresses, &addresses, &zero, false)
+			}
+		}
+
+		offset := lane*lanes + slice*segments + index
+		var random uint64
+		for index < segments {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += lanes
+			}
+			if dataIndependent {
+				if index%argon2BlockLength == 0 {
+					in[6]++
+					argon2ProcessBlock(&addresses, &in, &zero, false)
+					argon2ProcessBlock(&addresses, &addresses, &zero, false)
+				}
+				random = addresses[index%argon2BlockLength]
+			} else {
+				random = blocks[prev][0]
+			}
+			ref := argon2IndexAlpha(random, lanes, segments, threads, n, slice, lane, index)
+			argon2ProcessBlock(&blocks[offset], &blocks[prev], &blocks[ref], version != argon2Version10)
+			index, offset = index+1, offset+1
+		}
+	}
+
+	for n := uint32(0); n < time; n++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+			for lane := uint32(0); lane < threads; lane++ {
+				wg.Add(1)
+				go processSegment(n, slice, lane, &wg)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+func argon2ExtractKey(blocks []argon2Block, memory, threads, keyLen uint32) []byte {
+	lanes := memory / threads
+	for lane := uint32(0); lane < threads-1; lane++ {
+		for i, v := range blocks[lane*lanes+lanes-1] {
+			blocks[memory-1][i] ^= v
+		}
+	}
+
+	var block [1024]byte
+	for i, v := range blocks[memory-1] {
+		binary.LittleEndian.PutUint64(block[i*8:], v)
+	}
+	key := make([]byte, keyLen)
+	argon2Blake2bHash(key, block[:])
+	return key
+}
+
+func argon2IndexAlpha(random uint64, lanes, segments, threads, n, slice, lane, index uint32) uint32 {
+	refLane := uint32(random>>32) % threads
+	if n == 0 && slice == 0 {
+		refLane = lane
+	}
+	m, s := 3*segments, ((slice+1)%argon2SyncPoints)*segments
+	if lane == refLane {
+		m += index
+	}
+	if n == 0 {
+		m, s = slice*segments, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+	if index == 0 || lane == refLane {
+		m--
+	}
+
+	p := random & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * uint64(m)) >> 32
+	return refLane*lanes + uint32((uint64(s)+uint64(m)-(p+1))%uint64(lanes))
+}
+
+// argon2ProcessBlock computes the compression function G of in1 and in2
+// and stores it in out, XORed into its old contents when xor is set.
+func argon2ProcessBlock(out, in1, in2 *argon2Block, xor bool) {
+	var t argon2Block
+	for i := range t {
+		t[i] = in1[i] ^ in2[i]
+	}
+	for i := 0; i < argon2BlockLength; i += 16 {
+		argon2Blamka(
+			&t[i+0], &t[i+1], &t[i+2], &t[i+3],
+			&t[i+4], &t[i+5], &t[i+6], &t[i+7],
+			&t[i+8], &t[i+9], &t[i+10], &t[i+11],
+			&t[i+12], &t[i+13], &t[i+14], &t[i+15],
+		)
+	}
+	for i := 0; i < argon2BlockLength/8; i += 2 {
+		argon2Blamka(
+			&t[i], &t[i+1], &t[16+i], &t[16+i+1],
+			&t[32+i], &t[32+i+1], &t[48+i], &t[48+i+1],
+			&t[64+i], &t[64+i+1], &t[80+i], &t[80+i+1],
+			&t[96+i], &t[96+i+1], &t[112+i], &t[112+i+1],
+		)
+	}
+	for i := range t {
+		value := in1[i] ^ in2[i] ^ t[i]
+		if xor {
+			value ^= out[i]
+		}
+		out[i] = value
+	}
+}
+
+// argon2Blamka is one BLAKE2b round with the multiplication-hardened
+// mixing function, over the 16 words given.
+func argon2Blamka(t00, t01, t02, t03, t04, t05, t06, t07, t08, t09, t10, t11, t12, t13, t14, t15 *uint64) {
+	argon2G(t00, t04, t08, t12)
+	argon2G(t01, t05, t09, t13)
+	argon2G(t02, t06, t10, t14)
+	argon2G(t03, t07, t11, t15)
+
+	argon2G(t00, t05, t10, t15)
+	argon2G(t01, t06, t11, t12)
+	argon2G(t02, t07, t08, t13)
+	argon2G(t03, t04, t09, t14)
+}
+
+func argon2G(a, b, c, d *uint64) {
+	va, vb, vc, vd := *a, *b, *c, *d
+
+	va += vb + 2*uint64(uint32(va))*uint64(uint32(vb))
+	vd ^= va
+	vd = vd>>32 | vd<<32
+	vc += vd + 2*uint64(uint32(vc))*uint64(uint32(vd))
+	vb ^= vc
+	vb = vb>>24 | vb<<40
+
+	va += vb + 2*uint64(uint32(va))*uint64(uint32(vb))
+	vd ^= va
+	vd = vd>>16 | vd<<48
+	vc += vd + 2*uint64(uint32(vc))*uint64(uint32(vd))
+	vb ^= vc
+	vb = vb<<1 | vb>>63
+
+	*a, *b, *c, *d = va, vb, vc, vd
+}
+
+// argon2Blake2bHash is Argon2's variable-length hash H'.
+func argon2Blake2bHash(out []byte, in []byte) {
+	var b2 hash.Hash
+	if n := len(out); n < blake2b.Size {
+		b2, _ = blake2b.New(n, nil)
+	} else {
+		b2, _ = blake2b.New512(nil)
+	}
+
+	var buffer [blake2b.Size]byte
+	binary.LittleEndian.PutUint32(buffer[:4], uint32(len(out)))
+	b2.Write(buffer[:4])
+	b2.Write(in)
+
+	if len(out) <= blake2b.Size {
+		b2.Sum(out[:0])
+		return
+	}
+
+	outLen := len(out)
+	b2.Sum(buffer[:0])
+	b2.Reset()
+	copy(out, buffer[:32])
+	out = out[32:]
+	for len(out) > blake2b.Size {
+		b2.Write(buffer[:])
+		b2.Sum(buffer[:0])
+		copy(out, buffer[:32])
+		out = out[32:]
+		b2.Reset()
+	}
+
+	if outLen%blake2b.Size > 0 {
+		r := ((outLen + 31) / 32) - 2
+		b2, _ = blake2b.New(outLen-32*r, nil)
+	}
+	b2.Write(buffer[:])
+	b2.Sum(out[:0])
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestArgon2PortMatchesCurrentVersion(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+	for _, threads := range []uint8{1, 4} {
+		assert.Equal(t,
+			argon2.IDKey(password, salt, 2, 64, threads, 32),
+			argon2Derive(argon2ModeID, argon2Version13, password, salt, 2, 64, threads, 32),
+		)
+		assert.Equal(t,
+			argon2.Key(password, salt, 3, 64, threads, 64),
+			argon2Derive(argon2ModeI, argon2Version13, password, salt, 3, 64, threads, 64),
+		)
+	}
+}
+
+func TestArgon2Version10KnownAnswer(t *testing.T) {
+	// From the reference implementation's test suite (argon2i, version 0x10)
+	key := argon2Derive(argon2ModeI, argon2Version10, []byte("password"), []byte("somesalt"), 2, 1<<16, 1, 32)
+	assert.Equal(t, "f6c4db4a54e2a370627aff3db6176b94a2a209a62c8e36152711802f7b30c694", hex.EncodeToString(key))
+}
+
+func TestVerifyPasswordSecure(t *testing.T) {
+	hash, err := HashPasswordSecure("correct horse")
+	require.NoError(t, err)
+
+	match, err := VerifyPasswordSecure(hash, "correct horse")
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = VerifyPasswordSecure(hash, "wrong horse")
+	require.NoError(t, err)
+	assert.False(t, match)
+	assert.False(t, PasswordNeedsRehash(hash))
+}
+
+func TestVerifyPasswordSecureVersion10(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key := argon2IDVersion10([]byte("correct horse"), salt, argon2Params.TimeCost, argon2Params.MemoryCost, argon2Params.Threads, argon2Params.KeyLength)
+	hash := fmt.Sprintf(
+		"$argon2id$v=16$m=%d,t=%d,p=%d$%s$%s",
+		argon2Params.MemoryCost, argon2Params.TimeCost, argon2Params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	match, err := VerifyPasswordSecure(hash, "correct horse")
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, PasswordNeedsRehash(hash))
+
+	_, err = VerifyPasswordSecure("$argon2id$v=18$m=65536,t=2,p=4$c29tZXNhbHQ$c29tZWhhc2g", "correct horse")
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttemptRecord is the failure history of one key within its window.
+type AttemptRecord struct {
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"lastFailureAt"`
+}
+
+// AttemptStore counts failed attempts per key, e.g. per account or per
+// client IP. Each failure extends the key's window; once the window passes
+// without a failure the count starts over.
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (AttemptRecord, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (AttemptRecord, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// memoryAttemptSweepSize is how many keys the memory store holds before it
+// sweeps out expired ones.
+const memoryAttemptSweepSize = 10000
+
+type memoryAttempt struct {
+	AttemptRecord
+	expiresAt time.Time
+}
+
+// MemoryAttemptStore keeps counts in process. It suits a single instance;
+// with several replicas each one only sees its own share of the attempts.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]memoryAttempt
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]memoryAttempt)}
+}
+
+func (s *MemoryAttemptStore) Get(ctx context.Context, key string) (AttemptRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok || !attempt.expiresAt.After(time.Now()) {
+		return AttemptRecord{}, nil
+	}
+	return attempt.AttemptRecord, nil
+}
+
+func (s *MemoryAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (AttemptRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.attempts) >= memoryAttemptSweepSize {
+		for k, attempt := range s.attempts {
+			if !attempt.expiresAt.After(now) {
+				delete(s.attempts, k)
+			}
+		}
+	}
+
+	attempt := s.attempts[key]
+	if !attempt.expiresAt.After(now) {
+		attempt = memoryAttempt{}
+	}
+	attempt.Failures++
+	attempt.LastFailure = now
+	attempt.expiresAt = now.Add(window)
+	s.attempts[key] = attempt
+	return attempt.AttemptRecord, nil
+}
+
+func (s *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// MongoAttemptStore keeps counts in a collection so every replica sees the
+// same totals. The collection needs a TTL index on expiresAt.
+type MongoAttemptStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAttemptStore(collection *mongo.Collection) *MongoAttemptStore {
+	return &MongoAttemptStore{collection: collection}
+}
+
+func (s *MongoAttemptStore) Get(ctx context.Context, key string) (AttemptRecord, error) {
+	record := AttemptRecord{}
+	err := s.collection.FindOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return AttemptRecord{}, nil
+	}
+	return record, err
+}
+
+func (s *MongoAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (AttemptRecord, error) {
+	now := time.Now()
+
+	// The TTL monitor only runs once a minute, so clear a lapsed window
+	// ourselves before counting into it
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$lte": now}}); err != nil {
+		return AttemptRecord{}, err
+	}
+
+	record := AttemptRecord{}
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{"lastFailureAt": now, "expiresAt": now.Add(window)},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&record)
+	return record, err
+}
+
+func (s *MongoAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// NormalizeEmail validates a bare email address and lowercases it so the
+// unique index treats addresses case-insensitively.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return "", errors.New("invalid email address")
+	}
+	return strings.ToLower(email), nil
+}
@@ -2,39 +2,243 @@
 package utils
 
 import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
-var SecretKey = []byte(os.Getenv("JWT_SECERT"))
+// jwtKey is one configured JWT key. Only the active key signs new tokens;
+// every key verifies tokens and is published in the JWKS, which lets a
+// retired key keep working until the tokens it signed have expired.
+type jwtKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+var (
+	activeKey *jwtKey
+	jwtKeys   = map[string]*jwtKey{}
+)
+
+// JWK is the public half of a signing key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// InitJWTKeys loads the key ring from the environment:
+//
+//	JWT_SIGNING_KEYS=kid=path/to/private.pem[,kid=path...]  first entry signs
+//	JWT_VERIFY_KEYS=kid=path/to/public.pem[,kid=path...]    optional, verify only
+//
+// Ed25519 keys sign with EdDSA and RSA keys with RS256. It returns an error
+// when no usable signing key is configured.
+func InitJWTKeys() error {
+	signing, err := parseKeyList(os.Getenv("JWT_SIGNING_KEYS"))
+	if err != nil {
+		return fmt.Errorf("JWT_SIGNING_KEYS: %w", err)
+	}
+	if len(signing) == 0 {
+		return errors.New("JWT_SIGNING_KEYS is not set; at least one signing key is required")
+	}
+	verifying, err := parseKeyList(os.Getenv("JWT_VERIFY_KEYS"))
+	if err != nil {
+		return fmt.Errorf("JWT_VERIFY_KEYS: %w", err)
+	}
+
+	var active *jwtKey
+	keys := map[string]*jwtKey{}
+	for i, entry := range signing {
+		key, err := loadJWTKey(entry[0], entry[1], true)
+		if err != nil {
+			return err
+		}
+		if _, exists := keys[key.ID]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		keys[key.ID] = key
+		if i == 0 {
+			active = key
+		}
+	}
+	for _, entry := range verifying {
+		key, err := loadJWTKey(entry[0], entry[1], false)
+		if err != nil {
+			return err
+		}
+		if _, exists := keys[key.ID]; exists {
+			return fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	activeKey, jwtKeys = active, keys
+	return nil
+}
+
+func parseKeyList(value string) ([][2]string, error) {
+	entries := make([][2]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(item, "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected kid=path", item)
+		}
+		entries = append(entries, [2]string{kid, path})
+	}
+	return entries, nil
+}
 
-func GenerateJWT(email string, id string, validUntill int) (string, error) {
+func loadJWTKey(kid, path string, private bool) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWT key %q: %w", kid, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT key %q is not PEM encoded", kid)
+	}
+
+	key := &jwtKey{ID: kid}
+	if private {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return nil, fmt.Errorf("parsing JWT key %q: %w", kid, err)
+			}
+		}
+		switch k := parsed.(type) {
+		case ed25519.PrivateKey:
+			key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+		case *rsa.PrivateKey:
+			if k.N.BitLen() < 2048 {
+				return nil, fmt.Errorf("JWT key %q: RSA keys must be at least 2048 bits", kid)
+			}
+			key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+		default:
+			return nil, fmt.Errorf("JWT key %q: only Ed25519 and RSA keys are supported", kid)
+		}
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("parsing JWT key %q: %w", kid, err)
+		}
+	}
+	switch k := parsed.(type) {
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	default:
+		return nil, fmt.Errorf("JWT key %q: only Ed25519 and RSA keys are supported", kid)
+	}
+	return key, nil
+}
+
+// AccessClaims are the identity claims carried by an access token.
+type AccessClaims struct {
+	Email        string
+	UserID       string
+	SessionID    string
+	TokenVersion int
+	Role         string
+}
+
+func GenerateJWT(accessClaims AccessClaims, validUntill int) (string, error) {
 
 	claims := jwt.MapClaims{
-		"email": email,
-		"id":    id,
+		"email": accessClaims.Email,
+		"id":    accessClaims.UserID,
+		"sid":   accessClaims.SessionID,
+		"ver":   accessClaims.TokenVersion,
+		"role":  accessClaims.Role,
 		"exp":   time.Now().Add(time.Minute * time.Duration(validUntill)).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(SecretKey))
+	return signClaims(claims)
+}
+
+// GeneratePurposeJWT signs a short-lived token for a single purpose such as
+// an email verification link. Purpose tokens are never accepted as access
+// tokens, and ValidatePurposeJWT rejects them for any other purpose.
+func GeneratePurposeJWT(purpose string, claims map[string]interface{}, validUntill int) (string, error) {
+	tokenClaims := jwt.MapClaims{}
+	for key, value := range claims {
+		tokenClaims[key] = value
+	}
+	tokenClaims["purpose"] = purpose
+	tokenClaims["exp"] = time.Now().Add(time.Minute * time.Duration(validUntill)).Unix()
+
+	return signClaims(tokenClaims)
+}
+
+func ValidatePurposeJWT(tokenString string, purpose string) (jwt.MapClaims, error) {
+	_, claims, err := ValidateJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims["purpose"] != purpose {
+		return nil, errors.New("token purpose mismatch")
+	}
+	return claims, nil
+}
+
+func signClaims(claims jwt.MapClaims) (string, error) {
+	if activeKey == nil {
+		return "", errors.New("no JWT signing key configured")
+	}
+
+	token := jwt.NewWithClaims(activeKey.Method, claims)
+	token.Header["kid"] = activeKey.ID
+	signedToken, err := token.SignedString(activeKey.PrivateKey)
 	if err != nil {
 		return "", err
 	}
 
 	return signedToken, nil
-
 }
 
 func ValidateJWT(tokenString string) (*jwt.Token, jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return SecretKey, nil
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
@@ -56,3 +260,24 @@ func ValidateJWT(tokenString string) (*jwt.Token, jwt.MapClaims, error) {
 
 	return token, claims, nil
 }
+
+// PublicJWKS returns every configured key in JWKS form.
+func PublicJWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(jwtKeys))}
+	for _, key := range jwtKeys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch k := key.PublicKey.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
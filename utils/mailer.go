@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+// Mail is a plain-text email.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email such as password reset links.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+// SMTPMailer sends mail through an SMTP relay using PLAIN auth when a
+// username is set.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, mail Mail) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(mail.To, "\r\n") || strings.ContainsAny(mail.Subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	message := "From: " + m.From + "\r\n" +
+		"To: " + mail.To + "\r\n" +
+		"Subject: " + mail.Subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=\"utf-8\"\r\n" +
+		"\r\n" + mail.Body
+
+	addr := net.JoinHostPort(m.Host, m.Port)
+	if err := smtp.SendMail(addr, auth, m.From, []string{mail.To}, []byte(message)); err != nil {
+		return fmt.Errorf("smtp send failed: %w", err)
+	}
+	return nil
+}
+
+// memoryMailerLimit is how many mails MemoryMailer keeps; older ones are
+// dropped.
+const memoryMailerLimit = 100
+
+// MemoryMailer keeps the most recent mail in memory instead of sending it.
+// It is used in tests and for local development.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Mail
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, mail Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) >= memoryMailerLimit {
+		m.sent = append(m.sent[:0], m.sent[len(m.sent)-memoryMailerLimit+1:]...)
+	}
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+// Sent returns a copy of every mail sent so far.
+func (m *MemoryMailer) Sent() []Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Mail(nil), m.sent...)
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryMailerKeepsRecentMail(t *testing.T) {
+	mailer := &MemoryMailer{}
+	for i := 0; i < memoryMailerLimit+5; i++ {
+		require.NoError(t, mailer.Send(context.Background(), Mail{To: fmt.Sprintf("user%d@example.com", i)}))
+	}
+
+	sent := mailer.Sent()
+	require.Len(t, sent, memoryMailerLimit)
+	assert.Equal(t, "user5@example.com", sent[0].To)
+	assert.Equal(t, fmt.Sprintf("user%d@example.com", memoryMailerLimit+4), sent[len(sent)-1].To)
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuer is an OpenID Connect provider serving discovery, a JWKS and a
+// token endpoint that enforces PKCE, like a real one would.
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &mockIssuer{t: t, key: key, codes: map[string]mockAuthorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (m *mockIssuer) provider() *OIDCProvider {
+	return &OIDCProvider{
+		Name:        "mock",
+		Issuer:      m.server.URL,
+		ClientID:    "voxa-client",
+		RedirectURL: "https://voxa.example/callback",
+		Scopes:      []string{"openid", "email"},
+	}
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.server.URL,
+		"authorization_endpoint": m.server.URL + "/authorize",
+		"token_endpoint":         m.server.URL + "/token",
+		"jwks_uri":               m.server.URL + "/jwks",
+	})
+}
+
+func (m *mockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	auth, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"id_token": m.sign(auth.claims)})
+}
+
+func (m *mockIssuer) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "mock-key"
+	signed, err := token.SignedString(m.key)
+	require.NoError(m.t, err)
+	return signed
+}
+
+// authorize plays the user signing in at authURL and returns the code the
+// provider would redirect back with. edit may change the ID token claims.
+func (m *mockIssuer) authorize(authURL string, edit func(jwt.MapClaims)) string {
+	parsed, err := url.Parse(authURL)
+	require.NoError(m.t, err)
+	query := parsed.Query()
+	require.Equal(m.t, "S256", query.Get("code_challenge_method"))
+
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            query.Get("client_id"),
+		"sub":            "mock-user-1",
+		"email":          "ivan@example.com",
+		"email_verified": true,
+		"nonce":          query.Get("nonce"),
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+
+	code, err := GenerateOpaqueToken(16)
+	require.NoError(m.t, err)
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{challenge: query.Get("code_challenge"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+// signIn runs the authorization code flow up to a raw ID token.
+func signIn(t *testing.T, issuer *mockIssuer, provider *OIDCProvider, edit func(jwt.MapClaims)) (string, string) {
+	verifier, challenge, err := GeneratePKCE()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", challenge)
+	require.NoError(t, err)
+
+	code := issuer.authorize(authURL, edit)
+	idToken, err := provider.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+	return idToken, "nonce-1"
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+
+	idToken, nonce := signIn(t, issuer, provider, nil)
+	identity, err := provider.VerifyIDToken(context.Background(), idToken, nonce)
+	require.NoError(t, err)
+	assert.Equal(t, &OIDCIdentity{Subject: "mock-user-1", Email: "ivan@example.com", EmailVerified: true}, identity)
+}
+
+func TestOIDCExchangeRejectsWrongVerifier(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+
+	_, challenge, err := GeneratePKCE()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", challenge)
+	require.NoError(t, err)
+	code := issuer.authorize(authURL, nil)
+
+	otherVerifier, _, err := GeneratePKCE()
+	require.NoError(t, err)
+	_, err = provider.Exchange(context.Background(), code, otherVerifier)
+	assert.Error(t, err)
+}
+
+func TestOIDCVerifyIDTokenRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(jwt.MapClaims)
+		nonce   string
+		wantErr string
+	}{
+		{name: "nonce mismatch", nonce: "some-other-nonce", wantErr: "nonce mismatch"},
+		{name: "missing nonce", edit: func(c jwt.MapClaims) { delete(c, "nonce") }, wantErr: "nonce mismatch"},
+		{name: "wrong issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://attacker.example" }, wantErr: "issuer mismatch"},
+		{name: "wrong audience", edit: func(c jwt.MapClaims) { c["aud"] = "another-client" }, wantErr: "audience mismatch"},
+		{name: "wrong authorized party", edit: func(c jwt.MapClaims) { c["azp"] = "another-client" }, wantErr: "authorized party mismatch"},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, wantErr: "expired"},
+		{name: "no subject", edit: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: "no subject"},
+	}
+
+	issuer := newMockIssuer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := issuer.provider()
+			idToken, nonce := signIn(t, issuer, provider, tt.edit)
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			_, err := provider.VerifyIDToken(context.Background(), idToken, nonce)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestOIDCVerifyIDTokenRejectsForeignSignature(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+	idToken, nonce := signIn(t, issuer, provider, nil)
+
+	// The same claims signed by a key the provider never published
+	forger := newMockIssuer(t)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(idToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	forged := forger.sign(parsed.Claims.(jwt.MapClaims))
+
+	_, err = provider.VerifyIDToken(context.Background(), forged, nonce)
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/Investorharry19/voxa-golang-server/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// promptSlugSize bytes of randomness make an 8 character slug
+const promptSlugSize = 6
+
+// NewPromptSlug returns a random slug for a prompt's share link.
+func NewPromptSlug() (string, error) {
+	return GenerateOpaqueToken(promptSlugSize)
+}
+
+// EnsureDefaultPrompt returns userID's default prompt from prompts,
+// creating it the first time it is needed.
+func EnsureDefaultPrompt(ctx context.Context, prompts *mongo.Collection, userID primitive.ObjectID) (*models.Prompt, error) {
+	filter := bson.M{"userId": userID, "isDefault": true}
+
+	slug, err := NewPromptSlug()
+	if err != nil {
+		return nil, err
+	}
+	prompt := &models.Prompt{}
+	err = prompts.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$setOnInsert": bson.M{
+			"_id":       primitive.NewObjectID(),
+			"title":     models.DefaultPromptTitle,
+			"slug":      slug,
+			"position":  0,
+			"createdAt": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(prompt)
+	// A concurrent first use created it between our find and insert
+	if mongo.IsDuplicateKeyError(err) {
+		err = prompts.FindOne(ctx, filter).Decode(prompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prompt, nil
+}
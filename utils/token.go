@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateOpaqueToken returns a URL-safe random token carrying size bytes of
+// entropy.
+func GenerateOpaqueToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("token generation failed: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex SHA-256 of an opaque token. High-entropy tokens
+// don't need a slow hash, and a deterministic digest lets us look them up.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// PersonalAccessTokenPrefix marks personal access tokens so they can be told
+// apart from JWTs at a glance and picked up by secret scanners.
+const PersonalAccessTokenPrefix = "voxa_pat_"
+
+// GeneratePersonalAccessToken returns a new personal access token.
+func GeneratePersonalAccessToken() (string, error) {
+	token, err := GenerateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + token, nil
+}
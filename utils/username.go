@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 30
+)
+
+var (
+	ErrUsernameLength   = errors.New("Username must be between 3 and 30 characters")
+	ErrUsernameCharset  = errors.New("Username may only contain letters, digits and underscores")
+	ErrUsernameReserved = errors.New("This username is reserved")
+)
+
+// usernamePattern is ASCII only, which rules out lookalike letters from
+// other scripts once NFKC has folded fullwidth and compatibility forms.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// reservedUsernames would collide with routes, impersonate staff or confuse
+// clients. Compared case-insensitively.
+var reservedUsernames = map[string]bool{
+	"about": true, "account": true, "admin": true, "administrator": true,
+	"api": true, "help": true, "login": true, "logout": true, "me": true,
+	"message": true, "messages": true, "moderator": true, "null": true,
+	"official": true, "privacy": true, "register": true, "root": true,
+	"settings": true, "staff": true, "support": true, "swagger": true,
+	"system": true, "terms": true, "undefined": true, "voxa": true,
+}
+
+// NormalizeUsername applies the username policy to raw and returns the form
+// to store. Case is kept for display; uniqueness ignores it through the
+// collation on the users index.
+func NormalizeUsername(raw string) (string, error) {
+	username := strings.TrimSpace(norm.NFKC.String(raw))
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return "", ErrUsernameLength
+	}
+	if !usernamePattern.MatchString(username) {
+		return "", ErrUsernameCharset
+	}
+	if reservedUsernames[strings.ToLower(username)] {
+		return "", ErrUsernameReserved
+	}
+	return username, nil
+}